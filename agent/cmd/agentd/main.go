@@ -2,17 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"agentmarket/agent/internal/config"
+	"agentmarket/agent/internal/control"
+	"agentmarket/agent/internal/httpx"
 	"agentmarket/agent/internal/indexer"
 	"agentmarket/agent/internal/keys"
 	"agentmarket/agent/internal/llm"
@@ -22,6 +30,9 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
+// version is set at build time with -ldflags "-X main.version=<version>".
+var version = "dev"
+
 func main() {
 	sdkCfg := sdk.GetConfig()
 	sdkCfg.SetBech32PrefixForAccount("cosmos", "cosmospub")
@@ -34,7 +45,7 @@ func main() {
 
 	switch os.Args[1] {
 	case "init":
-		if err := cmdInit(); err != nil {
+		if err := cmdInit(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "init failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -53,6 +64,38 @@ func main() {
 			fmt.Fprintf(os.Stderr, "status failed: %v\n", err)
 			os.Exit(1)
 		}
+	case "keys":
+		if err := cmdKeys(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "keys failed: %v\n", err)
+			os.Exit(1)
+		}
+	case "config":
+		if err := cmdConfig(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "config failed: %v\n", err)
+			os.Exit(1)
+		}
+	case "panic":
+		if err := cmdPanic(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "panic failed: %v\n", err)
+			os.Exit(1)
+		}
+	case "serve":
+		if err := cmdServe(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "serve failed: %v\n", err)
+			os.Exit(1)
+		}
+	case "explain":
+		if err := cmdExplain(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "explain failed: %v\n", err)
+			os.Exit(1)
+		}
+	case "version":
+		fmt.Printf("agentd %s\n", version)
+	case "llm-check":
+		if err := cmdLLMCheck(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "llm-check failed: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		usage()
 		os.Exit(1)
@@ -60,35 +103,50 @@ func main() {
 }
 
 func usage() {
-	fmt.Println("agentd init | connect | run | status")
+	fmt.Println("agentd init | connect | run | serve | status | panic | keys rotate | config show | llm-check | explain | version")
 }
 
-func cmdInit() error {
+func cmdInit(args []string) error {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	addConfigFlag(fs)
+	keyStore := fs.String("key-store", "", "where to keep keys: a directory, keyring://[service] or env://[PREFIX] (default: keys/ next to the config)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return err
 	}
-	base := filepath.Join(home, ".agentmarket")
+	cfgPath, err := configPath()
+	if err != nil {
+		return err
+	}
+	base := filepath.Dir(cfgPath)
 	if err := os.MkdirAll(base, 0o700); err != nil {
 		return err
 	}
 
 	cfg := config.Default(home)
-	cfgPath := filepath.Join(base, "config.yaml")
-	if err := os.MkdirAll(cfg.Agent.KeyStore, 0o700); err != nil {
-		return err
+	// Keep keys and caches next to the config so each config is isolated.
+	cfg.Agent.KeyStore = filepath.Join(base, "keys")
+	if strings.TrimSpace(*keyStore) != "" {
+		cfg.Agent.KeyStore = strings.TrimSpace(*keyStore)
 	}
+	cfg.Agent.PauseFile = filepath.Join(base, "PAUSE")
+	cfg.Strategy.CacheDir = filepath.Join(base, "strategy")
 	if err := os.MkdirAll(cfg.Strategy.CacheDir, 0o700); err != nil {
 		return err
 	}
 
-	userKeyPath := keys.DefaultUserKeyPath(cfg.Agent.KeyStore)
-	agentKeyPath := keys.DefaultAgentKeyPath(cfg.Agent.KeyStore)
-	userKey, userCreated, err := keys.EnsureKey(userKeyPath, "user")
+	backend, err := keys.Open(cfg.Agent.KeyStore)
 	if err != nil {
 		return err
 	}
-	agentKey, agentCreated, err := keys.EnsureKey(agentKeyPath, "agent")
+	userKey, userCreated, err := keys.EnsureKey(backend, keys.UserKey)
+	if err != nil {
+		return err
+	}
+	agentKey, agentCreated, err := keys.EnsureKey(backend, keys.AgentKey)
 	if err != nil {
 		return err
 	}
@@ -102,33 +160,40 @@ func cmdInit() error {
 	fmt.Printf("user address:  %s\n", userKey.Address)
 	fmt.Printf("agent address: %s\n", agentKey.Address)
 	if userCreated || agentCreated {
-		fmt.Printf("keys stored in %s\n", cfg.Agent.KeyStore)
+		fmt.Printf("keys stored in %s\n", backend)
 	}
 	return nil
 }
 
 func cmdConnect(args []string) error {
 	fs := flag.NewFlagSet("connect", flag.ContinueOnError)
+	addConfigFlag(fs)
 	wait := fs.Bool("wait", false, "wait for payment + on-chain registration")
 	poll := fs.Duration("poll", 5*time.Second, "poll interval")
 	timeout := fs.Duration("timeout", 30*time.Minute, "wait timeout")
 	agentID := fs.String("agent-id", "", "agent address to register")
+	thenRun := fs.Bool("then-run", false, "start the runtime loop once registration completes (requires --wait)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *thenRun && !*wait {
+		return fmt.Errorf("--then-run requires --wait")
+	}
 
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	userKeyPath := keys.DefaultUserKeyPath(cfg.Agent.KeyStore)
-	agentKeyPath := keys.DefaultAgentKeyPath(cfg.Agent.KeyStore)
-	userKey, err := keys.Load(userKeyPath)
+	backend, err := keys.Open(cfg.Agent.KeyStore)
+	if err != nil {
+		return err
+	}
+	userKey, err := backend.Load(keys.UserKey)
 	if err != nil {
 		return fmt.Errorf("user key not found, run agentd init: %w", err)
 	}
-	agentKey, err := keys.Load(agentKeyPath)
+	agentKey, err := backend.Load(keys.AgentKey)
 	if err != nil {
 		return fmt.Errorf("agent key not found, run agentd init: %w", err)
 	}
@@ -142,6 +207,9 @@ func cmdConnect(args []string) error {
 	}
 
 	client := registrar.New(cfg.Registrar.URL)
+	if err := httpx.ConfigureTLS(client.HTTP, cfg.Registrar.TLS); err != nil {
+		return fmt.Errorf("registrar tls: %w", err)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	invoice, err := client.CreateInvoice(ctx, userKey.Address, selectedAgent)
 	cancel()
@@ -161,15 +229,27 @@ func cmdConnect(args []string) error {
 		return nil
 	}
 
-	deadline := time.Now().Add(*timeout)
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	waitCtx, cancelWait := context.WithTimeout(sigCtx, *timeout)
+	defer cancelWait()
+	interrupted := func() error {
+		fmt.Printf("\ninterrupted; invoice %s is still payable. Once paid, check with: agentd status\n", invoice.InvoiceID)
+		return nil
+	}
+	ticker := time.NewTicker(*poll)
+	defer ticker.Stop()
 	for {
-		if time.Now().After(deadline) {
-			return fmt.Errorf("timeout waiting for payment")
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(waitCtx, 10*time.Second)
 		inv, err := client.GetInvoice(ctx, invoice.InvoiceID)
 		cancel()
 		if err != nil {
+			if sigCtx.Err() != nil {
+				return interrupted()
+			}
+			if waitCtx.Err() != nil {
+				return fmt.Errorf("timeout waiting for payment")
+			}
 			return err
 		}
 		fmt.Printf("status: %s", inv.Status)
@@ -179,15 +259,31 @@ func cmdConnect(args []string) error {
 		fmt.Println()
 		if inv.Status == "paid" && inv.ChainTxHash != "" {
 			fmt.Printf("registered on-chain: %s\n", inv.ChainTxHash)
-			return nil
+			if !*thenRun {
+				return nil
+			}
+			return runAgent(sigCtx, cfg, selectedAgent, runOptions{})
+		}
+		select {
+		case <-sigCtx.Done():
+			return interrupted()
+		case <-waitCtx.Done():
+			return fmt.Errorf("timeout waiting for payment")
+		case <-ticker.C:
 		}
-		time.Sleep(*poll)
 	}
 }
 
 func cmdRun(args []string) error {
 	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	addConfigFlag(fs)
 	agentID := fs.String("agent-id", "", "agent address to run")
+	seed := fs.Int64("seed", 0, "seed for reproducible runs (0 = random)")
+	verbose := fs.Bool("verbose", false, "print the full prompt sent to the llm")
+	record := fs.String("record", "", "directory to write a JSON market snapshot to each cycle")
+	noJitter := fs.Bool("no-jitter", false, "make the first decision immediately instead of after a random startup delay")
+	promptFile := fs.String("strategy-prompt-file", "", "file whose contents replace the registered strategy prompt (overrides agent.strategy_prompt_file)")
+	anonymous := fs.Bool("anonymous", false, "run without an agent id (no balances, heartbeats or actions)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -195,53 +291,531 @@ func cmdRun(args []string) error {
 	if err != nil {
 		return err
 	}
+	if *promptFile != "" {
+		cfg.Agent.StrategyPromptFile = *promptFile
+	}
 	selected := strings.TrimSpace(*agentID)
 	if selected == "" {
 		selected = strings.TrimSpace(cfg.Agent.ID)
 	}
+	if err := requireAgentID(selected, *anonymous); err != nil {
+		return err
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	return runAgent(ctx, cfg, selected, runOptions{Seed: *seed, Verbose: *verbose, NoJitter: *noJitter, RecordDir: *record})
+}
 
-	llmClient, err := llm.New(llm.Config{
-		Provider:        cfg.LLM.Provider,
-		Model:           cfg.LLM.Model,
-		BaseURL:         cfg.LLM.BaseURL,
-		APIKey:          cfg.LLM.APIKey,
-		Temperature:     cfg.LLM.Temperature,
-		MaxOutputTokens: cfg.LLM.MaxOutputTokens,
-		TimeoutSeconds:  cfg.LLM.TimeoutSeconds,
-	})
+// requireAgentID refuses to start a run with no agent id, since every
+// agent-scoped call would silently no-op, unless --anonymous asks for that.
+func requireAgentID(selected string, anonymous bool) error {
+	if selected != "" {
+		return nil
+	}
+	if !anonymous {
+		return fmt.Errorf("agent id is required: pass --agent-id or set agent.id (or --anonymous to run without one)")
+	}
+	fmt.Println("warning: running anonymously; balances, heartbeats and actions are skipped")
+	return nil
+}
+
+// cmdServe runs the agent like cmdRun and also exposes the control API.
+func cmdServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addConfigFlag(fs)
+	agentID := fs.String("agent-id", "", "agent address to run")
+	addr := fs.String("addr", ":8099", "address for the control API to listen on")
+	promptFile := fs.String("strategy-prompt-file", "", "file whose contents replace the registered strategy prompt (overrides agent.strategy_prompt_file)")
+	anonymous := fs.Bool("anonymous", false, "run without an agent id (no balances, heartbeats or actions)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *promptFile != "" {
+		cfg.Agent.StrategyPromptFile = *promptFile
+	}
+	if strings.TrimSpace(cfg.Control.Token) == "" {
+		return fmt.Errorf("control.token (or AGENTD_CONTROL_TOKEN) is required for serve")
+	}
+	selected := strings.TrimSpace(*agentID)
+	if selected == "" {
+		selected = strings.TrimSpace(cfg.Agent.ID)
+	}
+	if err := requireAgentID(selected, *anonymous); err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return runAgent(ctx, cfg, selected, runOptions{ServeAddr: *addr, ControlToken: cfg.Control.Token})
+}
+
+// runOptions carries run-only command line settings into runAgent.
+type runOptions struct {
+	Seed      int64
+	Verbose   bool
+	NoJitter  bool
+	RecordDir string
+	// ServeAddr, when set, exposes the control API there for the run.
+	ServeAddr    string
+	ControlToken string
+}
+
+// serveControl starts the control API for runner and stops it when ctx is done.
+func serveControl(ctx context.Context, runner *runtime.Runner, addr, token string) error {
+	handler, err := control.NewHandler(runner, token)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("control api: %w", err)
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "control api stopped: %v\n", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Printf("control api listening on %s\n", ln.Addr())
+	return nil
+}
+
+func runAgent(ctx context.Context, cfg config.Config, selected string, opts runOptions) error {
+	backend, err := keys.Open(cfg.Agent.KeyStore)
+	if err != nil {
+		return err
+	}
+	// The agent run may differ from agent.id (--agent-id).
+	httpx.SetUserAgent(httpx.UserAgent(cfg.Network.UserAgent, version, selected))
+	lockDir := runLockDir(cfg)
+	release, locked, err := keys.AcquireRunLock(lockDir)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if !locked {
+		fmt.Printf("warning: run lock already held (%s); another agent may be running\n", keys.RunLockPath(lockDir))
+	}
+
+	profile := runtime.ResolveProfile(selected, cfg.Agent.Profile)
+	llmCfg := llmConfig(cfg, profile)
+	llmClient, err := llm.New(llmCfg)
 	if err != nil {
 		return err
 	}
+	// Without a model every cycle would only record a no_llm rejection.
+	if llmClient == nil {
+		return fmt.Errorf("no llm configured: set llm.provider (or LLM_PROVIDER) to openai, azure-openai, ollama or mock")
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	err = llmClient.Ping(pingCtx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("llm health check failed (%s/%s): %w", llmClient.Provider(), llmClient.Model(), err)
+	}
 
 	var idx *indexer.Client
 	if cfg.Chain.Indexer != "" {
 		ownerUID := strings.TrimSpace(os.Getenv("AGENT_OWNER_UID"))
 		idx = indexer.New(cfg.Chain.Indexer, ownerUID)
+		idx.CacheTTL = time.Duration(cfg.Indexer.AgentCacheSeconds) * time.Second
+		idx.Denoms = cfg.Chain.Denoms
+		idx.GzipMinBytes = cfg.Indexer.GzipMinBytes
+		if err := httpx.ConfigureTLS(idx.HTTP, cfg.Indexer.TLS); err != nil {
+			return fmt.Errorf("indexer tls: %w", err)
+		}
 	}
 
-	profile := strings.TrimSpace(os.Getenv("AGENT_PROFILE"))
 	userAddr := ""
-	if userKey, err := keys.Load(keys.DefaultUserKeyPath(cfg.Agent.KeyStore)); err == nil {
+	if userKey, err := backend.Load(keys.UserKey); err == nil {
 		userAddr = strings.TrimSpace(userKey.Address)
 	}
 	runner := runtime.NewRunnerWithProfile(selected, userAddr, llmClient, idx, profile)
+	applyRunnerConfig(runner, cfg)
+	runner.Temperature = llmCfg.Temperature
+	if idx != nil {
+		// The per-call contexts are the real bound; keep the client-wide
+		// timeout from cutting a longer configured one short.
+		for _, d := range []time.Duration{runner.Timeouts.Fetch, runner.Timeouts.Agent, runner.Timeouts.PostAction, runner.Timeouts.PostDecision, runner.Timeouts.Heartbeat} {
+			if d > idx.HTTP.Timeout {
+				idx.HTTP.Timeout = d
+			}
+		}
+	}
+	if opts.Seed != 0 {
+		runner.SetSeed(opts.Seed)
+	}
+	runner.Verbose = opts.Verbose
+	runner.NoStartJitter = opts.NoJitter
+	runner.RecordDir = strings.TrimSpace(opts.RecordDir)
+	if opts.ServeAddr != "" {
+		serveCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		if err := serveControl(serveCtx, runner, opts.ServeAddr, opts.ControlToken); err != nil {
+			return err
+		}
+	}
 	if selected == "" {
 		fmt.Println("agentd running")
 	} else {
 		fmt.Printf("agentd running for agent %s\n", selected)
-		if llmClient != nil {
-			fmt.Printf("llm provider: %s (%s)\n", llmClient.Provider(), llmClient.Model())
-		}
 	}
+	fmt.Printf("llm provider: %s (%s)\n", llmClient.Provider(), llmClient.Model())
+	if gateway := strings.TrimSpace(cfg.Agent.PushgatewayURL); gateway != "" {
+		defer func() {
+			pushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := runner.PushMetrics(pushCtx, gateway); err != nil {
+				fmt.Fprintf(os.Stderr, "metrics push failed: %v\n", err)
+			}
+		}()
+	}
+	return startRunner(ctx, runner)
+}
+
+// startRunner runs the runner runAgent built until ctx ends; tests swap it
+// to observe or stub that runner.
+var startRunner = func(ctx context.Context, runner *runtime.Runner) error {
 	return runner.Run(ctx)
 }
 
+// cmdPanic flattens an agent without the LLM: it cancels every open offer
+// and RFQ and, with --sell, sells all token holdings into the RFQ bids.
+func cmdPanic(args []string) error {
+	fs := flag.NewFlagSet("panic", flag.ContinueOnError)
+	addConfigFlag(fs)
+	agentID := fs.String("agent-id", "", "agent address to close out")
+	sell := fs.Bool("sell", false, "also sell all token holdings back to the settlement currency")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	selected := strings.TrimSpace(*agentID)
+	if selected == "" {
+		selected = strings.TrimSpace(cfg.Agent.ID)
+	}
+	if selected == "" {
+		return fmt.Errorf("agent id is required")
+	}
+	if cfg.Chain.Indexer == "" {
+		return fmt.Errorf("chain.indexer is not configured")
+	}
+	idx := indexer.New(cfg.Chain.Indexer, strings.TrimSpace(os.Getenv("AGENT_OWNER_UID")))
+	idx.Denoms = cfg.Chain.Denoms
+	idx.GzipMinBytes = cfg.Indexer.GzipMinBytes
+	if err := httpx.ConfigureTLS(idx.HTTP, cfg.Indexer.TLS); err != nil {
+		return fmt.Errorf("indexer tls: %w", err)
+	}
+	runner := runtime.NewRunnerWithProfile(selected, "", nil, idx, cfg.Agent.Profile)
+	applyRunnerConfig(runner, cfg)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	lines, err := runner.CloseAll(ctx, *sell)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	if len(lines) == 0 && err == nil {
+		fmt.Println("nothing to close")
+	}
+	return err
+}
+
+// cmdExplain prints a plain-language summary of how the agent is configured.
+func cmdExplain(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	addConfigFlag(fs)
+	agentID := fs.String("agent-id", "", "agent address to explain")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	selected := strings.TrimSpace(*agentID)
+	if selected == "" {
+		selected = strings.TrimSpace(cfg.Agent.ID)
+	}
+	var idx *indexer.Client
+	if cfg.Chain.Indexer != "" {
+		idx = indexer.New(cfg.Chain.Indexer, strings.TrimSpace(os.Getenv("AGENT_OWNER_UID")))
+		idx.Denoms = cfg.Chain.Denoms
+		idx.GzipMinBytes = cfg.Indexer.GzipMinBytes
+		if err := httpx.ConfigureTLS(idx.HTTP, cfg.Indexer.TLS); err != nil {
+			return fmt.Errorf("indexer tls: %w", err)
+		}
+	}
+	runner := runtime.NewRunnerWithProfile(selected, "", nil, idx, cfg.Agent.Profile)
+	applyRunnerConfig(runner, cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	runner.SyncAgentConfig(ctx)
+	cancel()
+	fmt.Println(runner.Explain())
+	return nil
+}
+
+// applyRunnerConfig copies the agent, llm and indexer timeout settings onto runner.
+func applyRunnerConfig(runner *runtime.Runner, cfg config.Config) {
+	runner.MaxReasonChars = cfg.Agent.MaxReasonChars
+	runner.MaxRawChars = cfg.Agent.MaxRawChars
+	runner.AssetCooldown = time.Duration(cfg.Agent.AssetCooldownSec) * time.Second
+	runner.MinConfidence = cfg.Agent.MinConfidence
+	if cfg.Agent.DefaultConfidence != nil {
+		runner.DefaultConfidence = *cfg.Agent.DefaultConfidence
+	}
+	runner.AutoRequote = cfg.Agent.AutoRequote
+	runner.RequoteDriftBps = cfg.Agent.RequoteDriftBps
+	runner.MaxDecisions = cfg.Agent.MaxDecisions
+	runner.ExitOnMaxDecisions = cfg.Agent.ExitOnMaxDecisions
+	runner.AllowedMsgs = cfg.Agent.AllowedMsgs
+	runner.AllowedCounterparties = cfg.Agent.AllowedCounterparties
+	runner.DeniedCounterparties = cfg.Agent.DeniedCounterparties
+	runner.BalancesTTL = time.Duration(cfg.Agent.BalancesTTLSec) * time.Second
+	runner.SettlementSymbol = cfg.Chain.SettlementSymbol
+	runner.MinAGCReserve = cfg.Agent.MinAGCReserve
+	runner.PriceBandBps = cfg.Agent.PriceBandBps
+	runner.SpreadBps = cfg.Agent.SpreadBps
+	runner.AssetSpreadBps = map[string]float64{}
+	for asset, bps := range cfg.Agent.AssetSpreadBps {
+		runner.AssetSpreadBps[strings.ToUpper(strings.TrimSpace(asset))] = bps
+	}
+	runner.TickSize = cfg.Agent.TickSize
+	runner.AssetTickSize = map[string]float64{}
+	for asset, tick := range cfg.Agent.AssetTickSize {
+		runner.AssetTickSize[strings.ToUpper(strings.TrimSpace(asset))] = tick
+	}
+	runner.ReduceOnly = cfg.Agent.ReduceOnly
+	runner.OrderTTLSec = cfg.Agent.OrderTTLSec
+	runner.MaxActionsPerMinute = cfg.Agent.MaxActionsPerMin
+	runner.MaxOrderEquityFraction = cfg.Agent.MaxOrderEquityFrac
+	runner.MinNotionalAGC = cfg.Agent.MinNotionalAGC
+	runner.MaxConsecutiveWaits = cfg.Agent.MaxConsecWaits
+	runner.StrategyPromptFile = strings.TrimSpace(cfg.Agent.StrategyPromptFile)
+	runner.StrictStrategyVersion = cfg.Agent.StrictStrategyVer
+	runner.WaitFallback = cfg.Agent.WaitFallback
+	runner.StrictParsing = cfg.Agent.StrictParsing
+	runner.LogLevel = cfg.Agent.LogLevel
+	runner.RFQFillMode = cfg.Agent.RFQFillMode
+	runner.RespondRFQs = cfg.Agent.RespondRFQs
+	runner.MaxMintQty = cfg.Agent.MaxMintQty
+	runner.ConfirmFills = time.Duration(cfg.Agent.ConfirmFillSec) * time.Second
+	runner.StalePriceAfter = time.Duration(cfg.Agent.StalePriceSec) * time.Second
+	runner.BlockStalePrices = cfg.Agent.BlockStalePrices
+	runner.MinVolume24H = cfg.Agent.MinVolume24H
+	runner.MinWait = time.Duration(cfg.Agent.MinWaitSec) * time.Second
+	runner.MaxWait = time.Duration(cfg.Agent.MaxWaitSec) * time.Second
+	runner.HeartbeatInterval = time.Duration(cfg.Agent.HeartbeatSec) * time.Second
+	runner.WarmupCycles = cfg.Agent.WarmupCycles
+	runner.Warmup = time.Duration(cfg.Agent.WarmupSec) * time.Second
+	runner.PauseFile = cfg.Agent.PauseFile
+	runner.OutboxSize = cfg.Agent.OutboxSize
+	runner.OperatorURL = strings.TrimSpace(cfg.Agent.OperatorURL)
+	runner.OperatorPing = time.Duration(cfg.Agent.OperatorPingSec) * time.Second
+	runner.OperatorTimeout = time.Duration(cfg.Agent.OperatorTimeoutSec) * time.Second
+	runner.OperatorCloseAll = cfg.Agent.OperatorCloseAll
+	runner.OutboxFile = strings.TrimSpace(cfg.Agent.OutboxFile)
+	runner.DecisionTimeout = time.Duration(cfg.LLM.DecisionTimeoutSeconds) * time.Second
+	runner.ContextTokens = cfg.LLM.ContextTokens
+	applyTimeout(&runner.Timeouts.Fetch, cfg.Indexer.Timeouts.FetchSeconds)
+	applyTimeout(&runner.Timeouts.Agent, cfg.Indexer.Timeouts.AgentSeconds)
+	applyTimeout(&runner.Timeouts.PostAction, cfg.Indexer.Timeouts.PostActionSeconds)
+	applyTimeout(&runner.Timeouts.PostDecision, cfg.Indexer.Timeouts.PostDecisionSeconds)
+	applyTimeout(&runner.Timeouts.Heartbeat, cfg.Indexer.Timeouts.HeartbeatSeconds)
+}
+
+// cmdLLMCheck sends one prompt to the configured model repeatedly and reports
+// how often the reply passes the runtime's strict action schema.
+func cmdLLMCheck(args []string) error {
+	fs := flag.NewFlagSet("llm-check", flag.ContinueOnError)
+	addConfigFlag(fs)
+	promptFile := fs.String("prompt-file", "", `JSON prompt {"system": "...", "user": "..."} (default: live prompt from the indexer)`)
+	runs := fs.Int("n", 10, "number of requests to send")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *runs <= 0 {
+		return fmt.Errorf("-n must be positive")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := llm.New(llmConfig(cfg, runtime.ResolveProfile(cfg.Agent.ID, cfg.Agent.Profile)))
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("no llm provider configured")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var prompt llm.Prompt
+	if path := strings.TrimSpace(*promptFile); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &prompt); err != nil {
+			return fmt.Errorf("parse prompt file: %w", err)
+		}
+	} else {
+		if cfg.Chain.Indexer == "" || strings.TrimSpace(cfg.Agent.ID) == "" {
+			return fmt.Errorf("no --prompt-file given and no indexer/agent configured for a live prompt")
+		}
+		idx := indexer.New(cfg.Chain.Indexer, strings.TrimSpace(os.Getenv("AGENT_OWNER_UID")))
+		idx.Denoms = cfg.Chain.Denoms
+		idx.GzipMinBytes = cfg.Indexer.GzipMinBytes
+		if err := httpx.ConfigureTLS(idx.HTTP, cfg.Indexer.TLS); err != nil {
+			return fmt.Errorf("indexer tls: %w", err)
+		}
+		runner := runtime.NewRunnerWithProfile(cfg.Agent.ID, "", client, idx, cfg.Agent.Profile)
+		applyRunnerConfig(runner, cfg)
+		prompt = runner.BuildPrompt(ctx)
+	}
+	if strings.TrimSpace(prompt.System) == "" && strings.TrimSpace(prompt.User) == "" {
+		return fmt.Errorf("prompt is empty")
+	}
+
+	valid := 0
+	actions := map[string]int{}
+	failures := map[string]int{}
+	var total, slowest time.Duration
+	fastest := time.Duration(-1)
+	for i := 0; i < *runs; i++ {
+		start := time.Now()
+		raw, err := client.Generate(ctx, prompt)
+		latency := time.Since(start)
+		total += latency
+		if latency > slowest {
+			slowest = latency
+		}
+		if fastest < 0 || latency < fastest {
+			fastest = latency
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			failures["llm error: "+err.Error()]++
+			continue
+		}
+		action, err := runtime.CheckResponse(raw, cfg.Chain.SettlementSymbol, cfg.Agent.StrictParsing)
+		if err != nil {
+			failures[err.Error()]++
+			continue
+		}
+		valid++
+		actions[action.Action]++
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "model\t%s/%s\n", client.Provider(), client.Model())
+	fmt.Fprintf(w, "valid\t%d/%d (%.0f%%)\n", valid, *runs, 100*float64(valid)/float64(*runs))
+	fmt.Fprintf(w, "latency\tavg %s, min %s, max %s\n",
+		(total / time.Duration(*runs)).Round(time.Millisecond), fastest.Round(time.Millisecond), slowest.Round(time.Millisecond))
+	for _, action := range sortedCounts(actions) {
+		fmt.Fprintf(w, "action %s\t%d\n", action, actions[action])
+	}
+	for _, reason := range sortedCounts(failures) {
+		fmt.Fprintf(w, "invalid\t%d × %s\n", failures[reason], reason)
+	}
+	return w.Flush()
+}
+
+// sortedCounts orders keys by descending count, then name.
+func sortedCounts(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// llmConfig builds the client config, including fallbacks, for an agent
+// running profile, applying any llm.profiles override on top of the llm
+// section. A blend uses the override of its heaviest profile.
+func llmConfig(cfg config.Config, profile string) llm.Config {
+	temperature := cfg.LLM.Temperature
+	maxTokens := cfg.LLM.MaxOutputTokens
+	if override, ok := cfg.LLM.Profiles[runtime.PrimaryProfile(profile)]; ok {
+		if override.Temperature != nil {
+			temperature = *override.Temperature
+		}
+		if override.MaxOutputTokens > 0 {
+			maxTokens = override.MaxOutputTokens
+		}
+	}
+	llmCfg := llm.Config{
+		Provider:        cfg.LLM.Provider,
+		Model:           cfg.LLM.Model,
+		BaseURL:         cfg.LLM.BaseURL,
+		APIKey:          cfg.LLM.APIKey,
+		APIKeyFile:      cfg.LLM.APIKeyFile,
+		Temperature:     temperature,
+		MaxOutputTokens: maxTokens,
+		TimeoutSeconds:  cfg.LLM.TimeoutSeconds,
+		MaxConcurrent:   cfg.LLM.MaxConcurrent,
+		APIStyle:        cfg.LLM.APIStyle,
+		ReasoningEffort: cfg.LLM.ReasoningEffort,
+		AzureDeployment: cfg.LLM.AzureDeployment,
+		AzureAPIVersion: cfg.LLM.AzureAPIVersion,
+		MockFile:        cfg.LLM.MockFile,
+		MockCycle:       cfg.LLM.MockCycle,
+	}
+	for _, fallback := range cfg.LLM.Fallbacks {
+		llmCfg.Fallbacks = append(llmCfg.Fallbacks, llm.Config{
+			Provider:        fallback.Provider,
+			Model:           fallback.Model,
+			BaseURL:         fallback.BaseURL,
+			APIKey:          fallback.APIKey,
+			APIKeyFile:      fallback.APIKeyFile,
+			Temperature:     temperature,
+			MaxOutputTokens: maxTokens,
+			TimeoutSeconds:  cfg.LLM.TimeoutSeconds,
+			MaxConcurrent:   cfg.LLM.MaxConcurrent,
+			APIStyle:        fallback.APIStyle,
+			AzureDeployment: fallback.AzureDeployment,
+			AzureAPIVersion: fallback.AzureAPIVersion,
+		})
+	}
+	return llmCfg
+}
+
+func applyTimeout(target *time.Duration, seconds int) {
+	if seconds > 0 {
+		*target = time.Duration(seconds) * time.Second
+	}
+}
+
 func cmdStatus(args []string) error {
 	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	addConfigFlag(fs)
 	agentID := fs.String("agent-id", "", "agent address to query")
+	showBalances := fs.Bool("balances", false, "also print the agent's holdings")
+	showUser := fs.Bool("user", false, "with --balances, also print the owning user's holdings")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -258,7 +832,12 @@ func cmdStatus(args []string) error {
 		return fmt.Errorf("agent id is required")
 	}
 
-	client := indexer.New(cfg.Chain.Indexer)
+	client := indexer.New(cfg.Chain.Indexer, strings.TrimSpace(os.Getenv("AGENT_OWNER_UID")))
+	client.Denoms = cfg.Chain.Denoms
+	client.GzipMinBytes = cfg.Indexer.GzipMinBytes
+	if err := httpx.ConfigureTLS(client.HTTP, cfg.Indexer.TLS); err != nil {
+		return fmt.Errorf("indexer tls: %w", err)
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	agent, err := client.GetAgent(ctx, selected)
 	cancel()
@@ -271,26 +850,215 @@ func cmdStatus(args []string) error {
 	fmt.Printf("  user: %s\n", agent.UserAddr)
 	fmt.Printf("  status: %s\n", agent.Status)
 	fmt.Printf("  strategy: %s (%s)\n", agent.StrategyURI, agent.StrategyVersion)
+	if !runtime.StrategyVersionSupported(agent.StrategyVersion) {
+		fmt.Printf("  warning: strategy version %s is not supported by this agentd (supports %s)\n", agent.StrategyVersion, runtime.SupportedStrategyVersions())
+	}
 	if strings.TrimSpace(agent.StrategyPrompt) != "" {
 		fmt.Printf("  strategy prompt: %s\n", agent.StrategyPrompt)
 	}
+	if len(agent.Policy.AllowedTokens) > 0 {
+		printAllowedTokens(client, agent.Policy.AllowedTokens)
+	}
+	if !*showBalances {
+		return nil
+	}
+	if err := printBalances(client, "agent balances", selected); err != nil {
+		return err
+	}
+	if *showUser && strings.TrimSpace(agent.UserAddr) != "" {
+		return printBalances(client, "user balances", agent.UserAddr)
+	}
+	return nil
+}
+
+// printAllowedTokens shows the policy's allowed tokens split into those the
+// market lists and those it does not (which the runtime ignores).
+func printAllowedTokens(client *indexer.Client, allowed []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	tokens, err := client.GetTokens(ctx)
+	cancel()
+	if err != nil {
+		fmt.Printf("  allowed tokens: %s (market check failed: %v)\n", strings.Join(allowed, ", "), err)
+		return
+	}
+	listed := map[string]bool{}
+	for _, token := range tokens {
+		listed[strings.ToUpper(strings.TrimSpace(token.Symbol))] = true
+	}
+	var known, unknown []string
+	for _, symbol := range allowed {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if listed[symbol] {
+			known = append(known, symbol)
+		} else if symbol != "" {
+			unknown = append(unknown, symbol)
+		}
+	}
+	if len(known) == 0 {
+		fmt.Printf("  allowed tokens: none listed in the market (%s); nothing is tradeable\n", strings.Join(unknown, ", "))
+		return
+	}
+	fmt.Printf("  allowed tokens: %s\n", strings.Join(known, ", "))
+	if len(unknown) > 0 {
+		fmt.Printf("  unknown tokens (ignored): %s\n", strings.Join(unknown, ", "))
+	}
+}
+
+func printBalances(client *indexer.Client, title, addr string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	balances, err := client.GetBalanceDetails(ctx, addr)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("%s: %w", title, err)
+	}
+	fmt.Printf("%s (%s)\n", title, addr)
+	if len(balances) == 0 {
+		fmt.Println("  none")
+		return nil
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Symbol != balances[j].Symbol {
+			return balances[i].Symbol < balances[j].Symbol
+		}
+		return balances[i].Denom < balances[j].Denom
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  symbol\tamount\tdenom")
+	for _, balance := range balances {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", balance.Symbol, balance, balance.Denom)
+	}
+	return w.Flush()
+}
+
+// cmdConfig prints the effective configuration: the config file with env
+// overrides applied, as run would see it, with secrets redacted.
+func cmdConfig(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return fmt.Errorf("usage: agentd config show")
+	}
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	addConfigFlag(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	cfgPath, err := configPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	b, err := config.Marshal(cfg.Redacted())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("# %s (with env overrides)\n%s", cfgPath, b)
+	return nil
+}
+
+func cmdKeys(args []string) error {
+	if len(args) == 0 || args[0] != "rotate" {
+		return fmt.Errorf("usage: agentd keys rotate --agent")
+	}
+	fs := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+	addConfigFlag(fs)
+	agent := fs.Bool("agent", false, "rotate the agent key")
+	force := fs.Bool("force", false, "rotate even if a run lock is present")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if !*agent {
+		return fmt.Errorf("nothing to rotate: pass --agent")
+	}
+	cfgPath, err := configPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		return fmt.Errorf("config not found, run agentd init: %w", err)
+	}
+	lockDir := runLockDir(cfg)
+	if keys.RunLocked(lockDir) && !*force {
+		return fmt.Errorf("an agent appears to be running (%s); stop it first or pass --force", keys.RunLockPath(lockDir))
+	}
+
+	backend, err := keys.Open(cfg.Agent.KeyStore)
+	if err != nil {
+		return err
+	}
+	oldKey, newKey, backup, err := keys.Rotate(backend, keys.AgentKey)
+	if err != nil {
+		return err
+	}
+	cfg.Agent.ID = newKey.Address
+	if err := config.Write(cfgPath, cfg); err != nil {
+		return err
+	}
+
+	fmt.Println("agent key rotated")
+	fmt.Printf("  old address: %s\n", oldKey.Address)
+	fmt.Printf("  new address: %s\n", newKey.Address)
+	fmt.Printf("  backup:      %s\n", backup)
+	fmt.Println("register the new address with: agentd connect")
 	return nil
 }
 
+// runLockDir is where the run lock lives: the key directory for file key
+// stores, else the config file's directory.
+func runLockDir(cfg config.Config) string {
+	fallback := "."
+	if cfgPath, err := configPath(); err == nil {
+		fallback = filepath.Dir(cfgPath)
+	}
+	return keys.LockDir(cfg.Agent.KeyStore, fallback)
+}
+
 func loadConfig() (config.Config, error) {
 	cfgPath, err := configPath()
 	if err != nil {
 		return config.Config{}, err
 	}
 	cfg, err := config.Load(cfgPath)
+	if errors.Is(err, os.ErrNotExist) && configFromEnv() {
+		home, homeErr := os.UserHomeDir()
+		if homeErr != nil {
+			return config.Config{}, homeErr
+		}
+		cfg, err = config.Default(home), nil
+	}
 	if err != nil {
 		return config.Config{}, fmt.Errorf("config not found, run agentd init: %w", err)
 	}
 	applyEnvOverrides(&cfg)
+	if err := cfg.Validate(); err != nil {
+		return config.Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := runtime.CheckProfile(cfg.Agent.Profile); err != nil {
+		return config.Config{}, fmt.Errorf("invalid config: agent.profile: %w", err)
+	}
+	if err := httpx.SetProxy(cfg.Network.ProxyURL); err != nil {
+		return config.Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	httpx.SetUserAgent(httpx.UserAgent(cfg.Network.UserAgent, version, cfg.Agent.ID))
 	return cfg, nil
 }
 
+// configFromEnv reports whether AGENTMARKET_CONFIG_FROM_ENV asks for the
+// defaults plus environment overrides when no config file exists.
+func configFromEnv() bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("AGENTMARKET_CONFIG_FROM_ENV")))
+	return err == nil && enabled
+}
+
 func applyEnvOverrides(cfg *config.Config) {
+	if v := strings.TrimSpace(os.Getenv("AGENT_ID")); v != "" {
+		cfg.Agent.ID = v
+	}
+	if v := strings.TrimSpace(os.Getenv("AGENT_KEY_STORE")); v != "" {
+		cfg.Agent.KeyStore = v
+	}
 	if v := strings.TrimSpace(os.Getenv("CHAIN_RPC_URL")); v != "" {
 		cfg.Chain.RPC = v
 	}
@@ -300,6 +1068,12 @@ func applyEnvOverrides(cfg *config.Config) {
 	if v := strings.TrimSpace(os.Getenv("REGISTRAR_URL")); v != "" {
 		cfg.Registrar.URL = v
 	}
+	if v := strings.TrimSpace(os.Getenv("AGENT_PROFILE")); v != "" {
+		cfg.Agent.Profile = v
+	}
+	if v := strings.TrimSpace(os.Getenv("AGENTD_CONTROL_TOKEN")); v != "" {
+		cfg.Control.Token = v
+	}
 	if v := strings.TrimSpace(os.Getenv("LLM_PROVIDER")); v != "" {
 		cfg.LLM.Provider = v
 	}
@@ -315,6 +1089,9 @@ func applyEnvOverrides(cfg *config.Config) {
 	if v := strings.TrimSpace(os.Getenv("OPENAI_API_KEY")); v != "" && cfg.LLM.APIKey == "" {
 		cfg.LLM.APIKey = v
 	}
+	if v := strings.TrimSpace(os.Getenv("LLM_API_KEY_FILE")); v != "" {
+		cfg.LLM.APIKeyFile = v
+	}
 	if v := strings.TrimSpace(os.Getenv("OLLAMA_HOST")); v != "" && cfg.LLM.BaseURL == "" {
 		cfg.LLM.BaseURL = v
 	}
@@ -333,9 +1110,52 @@ func applyEnvOverrides(cfg *config.Config) {
 			cfg.LLM.TimeoutSeconds = value
 		}
 	}
+	if v := strings.TrimSpace(os.Getenv("LLM_DECISION_TIMEOUT_SECONDS")); v != "" {
+		if value, err := strconv.Atoi(v); err == nil {
+			cfg.LLM.DecisionTimeoutSeconds = value
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_MAX_CONCURRENT")); v != "" {
+		if value, err := strconv.Atoi(v); err == nil {
+			cfg.LLM.MaxConcurrent = value
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_CONTEXT_TOKENS")); v != "" {
+		if value, err := strconv.Atoi(v); err == nil {
+			cfg.LLM.ContextTokens = value
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_API_STYLE")); v != "" {
+		cfg.LLM.APIStyle = v
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_REASONING_EFFORT")); v != "" {
+		cfg.LLM.ReasoningEffort = v
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_AZURE_DEPLOYMENT")); v != "" {
+		cfg.LLM.AzureDeployment = v
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_AZURE_API_VERSION")); v != "" {
+		cfg.LLM.AzureAPIVersion = v
+	}
+	if v := strings.TrimSpace(os.Getenv("LLM_MOCK_FILE")); v != "" {
+		cfg.LLM.MockFile = v
+	}
+}
+
+// configOverride is set by --config on any subcommand.
+var configOverride string
+
+func addConfigFlag(fs *flag.FlagSet) {
+	fs.StringVar(&configOverride, "config", "", "config file path (default ~/.agentmarket/config.yaml, or $AGENTMARKET_CONFIG)")
 }
 
 func configPath() (string, error) {
+	if path := strings.TrimSpace(configOverride); path != "" {
+		return path, nil
+	}
+	if path := strings.TrimSpace(os.Getenv("AGENTMARKET_CONFIG")); path != "" {
+		return path, nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agentmarket/agent/internal/config"
+	"agentmarket/agent/internal/indexer/indexertest"
+	"agentmarket/agent/internal/registrar"
+	"agentmarket/agent/internal/runtime"
+)
+
+// initTestConfig runs init under a temporary HOME and returns the config
+// path and config, pointed at the mock LLM and no indexer.
+func initTestConfig(t *testing.T) (string, config.Config) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Cleanup(func() { configOverride = "" })
+	cfgPath := filepath.Join(dir, "config.yaml")
+	if err := cmdInit([]string{"--config", cfgPath}); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	mockFile := filepath.Join(dir, "mock.jsonl")
+	if err := os.WriteFile(mockFile, []byte(`{"action":"wait","next_check_sec":1,"reason":"test"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Chain.Indexer = ""
+	cfg.LLM.Provider = "mock"
+	cfg.LLM.MockFile = mockFile
+	cfg.LLM.MockCycle = true
+	return cfgPath, cfg
+}
+
+// stubRunner swaps startRunner for one that runs briefly against stub.
+func stubRunner(t *testing.T, stub *indexertest.Indexer, started **runtime.Runner) {
+	t.Helper()
+	orig := startRunner
+	t.Cleanup(func() { startRunner = orig })
+	startRunner = func(ctx context.Context, runner *runtime.Runner) error {
+		*started = runner
+		runner.Indexer = stub
+		runner.NoStartJitter = true
+		runCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+		if err := runner.Run(runCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestConnectThenRunStartsRegisteredAgent(t *testing.T) {
+	cfgPath, cfg := initTestConfig(t)
+
+	var mu sync.Mutex
+	registered := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/invoices":
+			var req registrar.CreateInvoiceRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			registered = req.AgentAddr
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(registrar.Invoice{InvoiceID: "inv-1", Status: "pending"})
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/invoices/inv-1":
+			_ = json.NewEncoder(w).Encode(registrar.Invoice{InvoiceID: "inv-1", Status: "paid", ChainTxHash: "0xabc"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg.Registrar.URL = srv.URL
+	if err := config.Write(cfgPath, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	stub := indexertest.New()
+	var started *runtime.Runner
+	stubRunner(t, stub, &started)
+
+	if err := cmdConnect([]string{"--config", cfgPath, "--wait", "--then-run", "--poll", "10ms"}); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if started == nil {
+		t.Fatal("--then-run did not start the runner")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if registered != cfg.Agent.ID {
+		t.Fatalf("registered agent %q, want %q", registered, cfg.Agent.ID)
+	}
+	if started.AgentID != registered {
+		t.Fatalf("runner started for agent %q, want the registered %q", started.AgentID, registered)
+	}
+	heartbeats := stub.Heartbeats()
+	if len(heartbeats) == 0 {
+		t.Fatal("runner posted no heartbeats")
+	}
+	if heartbeats[0].AgentID != registered {
+		t.Fatalf("heartbeat for agent %q, want %q", heartbeats[0].AgentID, registered)
+	}
+}
+
+func TestRunPushesMetricsOnExit(t *testing.T) {
+	_, cfg := initTestConfig(t)
+
+	type push struct {
+		method, path, contentType, body string
+	}
+	var mu sync.Mutex
+	var pushes []push
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		pushes = append(pushes, push{r.Method, r.URL.Path, r.Header.Get("Content-Type"), string(body)})
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+	cfg.Agent.PushgatewayURL = gateway.URL
+
+	stub := indexertest.New()
+	var started *runtime.Runner
+	stubRunner(t, stub, &started)
+	// Nothing may reach the gateway while the runner is still running.
+	orig := startRunner
+	startRunner = func(ctx context.Context, runner *runtime.Runner) error {
+		err := orig(ctx, runner)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(pushes) != 0 {
+			t.Errorf("metrics pushed before Run returned: %+v", pushes)
+		}
+		return err
+	}
+
+	if err := runAgent(context.Background(), cfg, cfg.Agent.ID, runOptions{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if started == nil {
+		t.Fatal("runAgent did not start the runner")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushes) != 1 {
+		t.Fatalf("got %d pushes, want 1", len(pushes))
+	}
+	got := pushes[0]
+	if got.method != http.MethodPut {
+		t.Fatalf("push method %s, want PUT", got.method)
+	}
+	if want := "/metrics/job/agentd/instance/" + cfg.Agent.ID; got.path != want {
+		t.Fatalf("push path %q, want %q", got.path, want)
+	}
+	if !strings.HasPrefix(got.contentType, "text/plain") {
+		t.Fatalf("push content type %q", got.contentType)
+	}
+	if !strings.Contains(got.body, "agentd_cycles_total") {
+		t.Fatalf("push body has no cycle counter:\n%s", got.body)
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"agentmarket/agent/internal/httpx"
+	"agentmarket/agent/internal/indexer"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,16 +16,102 @@ type Config struct {
 	Chain struct {
 		RPC     string `yaml:"rpc"`
 		Indexer string `yaml:"indexer"`
+		// SettlementSymbol is the market's base currency (default AGC).
+		SettlementSymbol string `yaml:"settlement_symbol"`
+		// Denoms maps on-chain denoms (e.g. uagc) to the symbols and whole
+		// units the agent reasons in.
+		Denoms []indexer.Denom `yaml:"denoms"`
 	} `yaml:"chain"`
 	Registrar struct {
-		URL string `yaml:"url"`
+		URL string         `yaml:"url"`
+		TLS httpx.TLSFiles `yaml:"tls"`
 	} `yaml:"registrar"`
+	Network struct {
+		// ProxyURL overrides HTTP_PROXY/HTTPS_PROXY for all outbound calls.
+		ProxyURL string `yaml:"proxy_url"`
+		// UserAgent is the product name in the User-Agent sent on every
+		// request, followed by the agentd version and agent ID (default agentd).
+		UserAgent string `yaml:"user_agent"`
+	} `yaml:"network"`
+	Control struct {
+		// Token is the bearer token `agentd serve` requires on every request.
+		Token string `yaml:"token"`
+	} `yaml:"control"`
+	Indexer struct {
+		// GzipMinBytes compresses POST bodies at least this large (0 = off).
+		GzipMinBytes int `yaml:"gzip_min_bytes"`
+		Timeouts     struct {
+			FetchSeconds        int `yaml:"fetch_seconds"`
+			AgentSeconds        int `yaml:"agent_seconds"`
+			PostActionSeconds   int `yaml:"post_action_seconds"`
+			PostDecisionSeconds int `yaml:"post_decision_seconds"`
+			HeartbeatSeconds    int `yaml:"heartbeat_seconds"`
+		} `yaml:"timeouts"`
+		// AgentCacheSeconds memoizes agent lookups during `run` (0 = off).
+		AgentCacheSeconds int `yaml:"agent_cache_seconds"`
+		// TLS enables client-certificate auth against an mTLS indexer.
+		TLS httpx.TLSFiles `yaml:"tls"`
+	} `yaml:"indexer"`
 	Agent struct {
 		ID                 string   `yaml:"id"`
 		KeyStore           string   `yaml:"key_store"`
 		SessionTTLMinutes  int      `yaml:"session_ttl_minutes"`
 		SessionMaxSpendAGC uint64   `yaml:"session_max_spend_agc"`
 		AllowedMsgs        []string `yaml:"allowed_msgs"`
+		MaxReasonChars     int      `yaml:"max_reason_chars"`
+		MaxRawChars        int      `yaml:"max_raw_chars"`
+		AssetCooldownSec   int      `yaml:"asset_cooldown_seconds"`
+		MinConfidence      float64  `yaml:"min_confidence"`
+		DefaultConfidence  *float64 `yaml:"default_confidence,omitempty"`
+		PushgatewayURL     string   `yaml:"pushgateway_url"`
+		AutoRequote        bool     `yaml:"auto_requote"`
+		RequoteDriftBps    float64  `yaml:"requote_drift_bps"`
+		MaxDecisions       int      `yaml:"max_decisions"`
+		ExitOnMaxDecisions bool     `yaml:"exit_on_max_decisions"`
+		BalancesTTLSec     int      `yaml:"balances_ttl_seconds"`
+		MinAGCReserve      uint64   `yaml:"min_agc_reserve"`
+		PriceBandBps       float64  `yaml:"price_band_bps"`
+		SpreadBps          float64  `yaml:"spread_bps"`
+		TickSize           float64  `yaml:"tick_size"`
+		ReduceOnly         bool     `yaml:"reduce_only"`
+		OrderTTLSec        int      `yaml:"order_ttl_seconds"`
+		MaxActionsPerMin   int      `yaml:"max_actions_per_minute"`
+		MaxOrderEquityFrac float64  `yaml:"max_order_equity_fraction"`
+		MinNotionalAGC     float64  `yaml:"min_notional_agc"`
+		MaxConsecWaits     int      `yaml:"max_consecutive_waits"`
+		WaitFallback       bool     `yaml:"wait_fallback"`
+		RFQFillMode        string   `yaml:"rfq_fill_mode"`
+		RespondRFQs        bool     `yaml:"respond_rfqs"`
+		MaxMintQty         uint64   `yaml:"max_mint_qty"`
+		StrictParsing      bool     `yaml:"strict_parsing"`
+		LogLevel           string   `yaml:"log_level"`
+		ConfirmFillSec     int      `yaml:"confirm_fill_seconds"`
+		StalePriceSec      int      `yaml:"stale_price_seconds"`
+		BlockStalePrices   bool     `yaml:"block_stale_prices"`
+		MinVolume24H       float64  `yaml:"min_volume_24h"`
+		MinWaitSec         int      `yaml:"min_wait_seconds"`
+		MaxWaitSec         int      `yaml:"max_wait_seconds"`
+		HeartbeatSec       int      `yaml:"heartbeat_seconds"`
+		WarmupCycles       int      `yaml:"warmup_cycles"`
+		WarmupSec          int      `yaml:"warmup_seconds"`
+		PauseFile          string   `yaml:"pause_file"`
+		Profile            string   `yaml:"profile"`
+		StrategyPromptFile string   `yaml:"strategy_prompt_file"`
+		StrictStrategyVer  bool     `yaml:"strict_strategy_version"`
+		OutboxSize         int      `yaml:"outbox_size"`
+		OutboxFile         string   `yaml:"outbox_file"`
+		// Counterparty agent IDs to trade exclusively with / never trade with.
+		AllowedCounterparties []string `yaml:"allowed_counterparties"`
+		DeniedCounterparties  []string `yaml:"denied_counterparties"`
+		// AssetSpreadBps overrides spread_bps per asset symbol (market_maker only).
+		AssetSpreadBps map[string]float64 `yaml:"asset_spread_bps"`
+		// AssetTickSize overrides tick_size per asset symbol.
+		AssetTickSize map[string]float64 `yaml:"asset_tick_size"`
+		// Operator* configure the dead-man's switch (see agentd README).
+		OperatorURL        string `yaml:"operator_url"`
+		OperatorPingSec    int    `yaml:"operator_ping_seconds"`
+		OperatorTimeoutSec int    `yaml:"operator_timeout_seconds"`
+		OperatorCloseAll   bool   `yaml:"operator_close_all"`
 	} `yaml:"agent"`
 	Strategy struct {
 		FetchTimeoutSeconds int    `yaml:"fetch_timeout_seconds"`
@@ -31,22 +122,73 @@ type Config struct {
 		Model           string  `yaml:"model"`
 		BaseURL         string  `yaml:"base_url"`
 		APIKey          string  `yaml:"api_key"`
+		APIKeyFile      string  `yaml:"api_key_file"`
 		Temperature     float64 `yaml:"temperature"`
 		MaxOutputTokens int     `yaml:"max_output_tokens"`
 		TimeoutSeconds  int     `yaml:"timeout_seconds"`
+		// DecisionTimeoutSeconds caps one decision across all retries.
+		DecisionTimeoutSeconds int    `yaml:"decision_timeout_seconds"`
+		MaxConcurrent          int    `yaml:"max_concurrent"`
+		ContextTokens          int    `yaml:"context_tokens"`
+		APIStyle               string `yaml:"api_style"`
+		ReasoningEffort        string `yaml:"reasoning_effort"`
+		AzureDeployment        string `yaml:"azure_deployment"`
+		AzureAPIVersion        string `yaml:"azure_api_version"`
+		MockFile               string `yaml:"mock_file"`
+		MockCycle              bool   `yaml:"mock_cycle"`
+		// Fallbacks are tried in order when the primary provider fails.
+		Fallbacks []LLMFallback `yaml:"fallbacks"`
+		// Profiles overrides sampling settings per agent profile, keyed by
+		// profile name (market_maker, taker, momentum, arbitrage).
+		Profiles map[string]LLMProfile `yaml:"profiles"`
 	} `yaml:"llm"`
 }
 
+// LLMProfile overrides the llm sampling settings for one agent profile.
+// Unset fields keep the llm section's values.
+type LLMProfile struct {
+	Temperature     *float64 `yaml:"temperature,omitempty"`
+	MaxOutputTokens int      `yaml:"max_output_tokens,omitempty"`
+}
+
+// LLMFallback is a secondary provider. Sampling, timeout and concurrency
+// settings are inherited from the primary llm section.
+type LLMFallback struct {
+	Provider        string `yaml:"provider"`
+	Model           string `yaml:"model"`
+	BaseURL         string `yaml:"base_url"`
+	APIKey          string `yaml:"api_key"`
+	APIKeyFile      string `yaml:"api_key_file"`
+	APIStyle        string `yaml:"api_style"`
+	AzureDeployment string `yaml:"azure_deployment"`
+	AzureAPIVersion string `yaml:"azure_api_version"`
+}
+
 func Default(home string) Config {
 	cfg := Config{}
 	cfg.Chain.RPC = "http://localhost:26657"
 	cfg.Chain.Indexer = "http://localhost:8080"
+	cfg.Chain.SettlementSymbol = "AGC"
 	cfg.Registrar.URL = "http://localhost:7070"
+	cfg.Indexer.Timeouts.FetchSeconds = 3
+	cfg.Indexer.Timeouts.AgentSeconds = 2
+	cfg.Indexer.Timeouts.PostActionSeconds = 5
+	cfg.Indexer.Timeouts.PostDecisionSeconds = 3
+	cfg.Indexer.Timeouts.HeartbeatSeconds = 2
+	cfg.Indexer.AgentCacheSeconds = 5
 	cfg.Agent.ID = ""
 	cfg.Agent.KeyStore = filepath.Join(home, ".agentmarket", "keys")
+	cfg.Agent.PauseFile = filepath.Join(home, ".agentmarket", "PAUSE")
 	cfg.Agent.SessionTTLMinutes = 10
 	cfg.Agent.SessionMaxSpendAGC = 50
 	cfg.Agent.AllowedMsgs = []string{"MsgPostOffer", "MsgCreateRFQ"}
+	cfg.Agent.MaxReasonChars = 280
+	cfg.Agent.MaxRawChars = 2048
+	cfg.Agent.AssetCooldownSec = 30
+	cfg.Agent.RequoteDriftBps = 100
+	cfg.Agent.BalancesTTLSec = 5
+	cfg.Agent.PriceBandBps = 500
+	cfg.Agent.HeartbeatSec = 15
 	cfg.Strategy.FetchTimeoutSeconds = 10
 	cfg.Strategy.CacheDir = filepath.Join(home, ".agentmarket", "strategy")
 	cfg.LLM.Provider = ""
@@ -56,6 +198,7 @@ func Default(home string) Config {
 	cfg.LLM.Temperature = 0.2
 	cfg.LLM.MaxOutputTokens = 256
 	cfg.LLM.TimeoutSeconds = 15
+	cfg.LLM.DecisionTimeoutSeconds = 30
 	return cfg
 }
 
@@ -71,10 +214,147 @@ func Load(path string) (Config, error) {
 	return cfg, nil
 }
 
+// Validate rejects settings that can never work. Zero values are allowed
+// wherever the runtime falls back to a default.
+func (c Config) Validate() error {
+	timeouts := map[string]int{
+		"indexer.timeouts.fetch_seconds":         c.Indexer.Timeouts.FetchSeconds,
+		"indexer.timeouts.agent_seconds":         c.Indexer.Timeouts.AgentSeconds,
+		"indexer.timeouts.post_action_seconds":   c.Indexer.Timeouts.PostActionSeconds,
+		"indexer.timeouts.post_decision_seconds": c.Indexer.Timeouts.PostDecisionSeconds,
+		"indexer.timeouts.heartbeat_seconds":     c.Indexer.Timeouts.HeartbeatSeconds,
+		"llm.decision_timeout_seconds":           c.LLM.DecisionTimeoutSeconds,
+		"llm.context_tokens":                     c.LLM.ContextTokens,
+	}
+	if c.Indexer.GzipMinBytes < 0 {
+		return fmt.Errorf("indexer.gzip_min_bytes must not be negative (got %d)", c.Indexer.GzipMinBytes)
+	}
+	for name, value := range timeouts {
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative (got %d)", name, value)
+		}
+	}
+	for profile, override := range c.LLM.Profiles {
+		if override.MaxOutputTokens < 0 {
+			return fmt.Errorf("llm.profiles.%s.max_output_tokens must not be negative (got %d)", profile, override.MaxOutputTokens)
+		}
+	}
+	seenDenoms := map[string]bool{}
+	seenSymbols := map[string]bool{}
+	for _, d := range c.Chain.Denoms {
+		if strings.TrimSpace(d.Symbol) == "" || strings.TrimSpace(d.Denom) == "" {
+			return fmt.Errorf("chain.denoms entries need both symbol and denom")
+		}
+		if d.Exponent < 0 || d.Exponent > 18 {
+			return fmt.Errorf("chain.denoms.%s exponent must be between 0 and 18 (got %d)", d.Denom, d.Exponent)
+		}
+		key := strings.ToLower(strings.TrimSpace(d.Denom))
+		if seenDenoms[key] {
+			return fmt.Errorf("chain.denoms lists %s twice", d.Denom)
+		}
+		seenDenoms[key] = true
+		// The mapping runs both ways, so a symbol may name only one denom.
+		symbol := strings.ToUpper(strings.TrimSpace(d.Symbol))
+		if seenSymbols[symbol] {
+			return fmt.Errorf("chain.denoms maps %s to more than one denom", d.Symbol)
+		}
+		seenSymbols[symbol] = true
+	}
+	if c.Agent.MaxOrderEquityFrac < 0 || c.Agent.MaxOrderEquityFrac > 1 {
+		return fmt.Errorf("agent.max_order_equity_fraction must be between 0 and 1 (got %g)", c.Agent.MaxOrderEquityFrac)
+	}
+	if c.Agent.OperatorPingSec < 0 || c.Agent.OperatorTimeoutSec < 0 {
+		return fmt.Errorf("agent.operator_ping_seconds and agent.operator_timeout_seconds must not be negative")
+	}
+	if c.Agent.OutboxSize < 0 {
+		return fmt.Errorf("agent.outbox_size must not be negative (got %d)", c.Agent.OutboxSize)
+	}
+	if c.Agent.MinWaitSec < 0 || c.Agent.MaxWaitSec < 0 {
+		return fmt.Errorf("agent.min_wait_seconds and agent.max_wait_seconds must not be negative")
+	}
+	if c.Agent.MaxWaitSec > 0 && c.Agent.MinWaitSec > c.Agent.MaxWaitSec {
+		return fmt.Errorf("agent.min_wait_seconds (%d) must not exceed agent.max_wait_seconds (%d)", c.Agent.MinWaitSec, c.Agent.MaxWaitSec)
+	}
+	if c.Agent.MinNotionalAGC < 0 {
+		return fmt.Errorf("agent.min_notional_agc must not be negative (got %g)", c.Agent.MinNotionalAGC)
+	}
+	if c.Agent.MinVolume24H < 0 {
+		return fmt.Errorf("agent.min_volume_24h must not be negative (got %g)", c.Agent.MinVolume24H)
+	}
+	if c.Agent.ConfirmFillSec < 0 {
+		return fmt.Errorf("agent.confirm_fill_seconds must not be negative (got %d)", c.Agent.ConfirmFillSec)
+	}
+	if c.Agent.StalePriceSec < 0 {
+		return fmt.Errorf("agent.stale_price_seconds must not be negative (got %d)", c.Agent.StalePriceSec)
+	}
+	if c.Agent.MaxReasonChars < 0 || (c.Agent.MaxReasonChars > 0 && c.Agent.MaxReasonChars < 4) {
+		return fmt.Errorf("agent.max_reason_chars must be 0 or at least 4 (got %d)", c.Agent.MaxReasonChars)
+	}
+	if c.Agent.MaxConsecWaits < 0 {
+		return fmt.Errorf("agent.max_consecutive_waits must not be negative (got %d)", c.Agent.MaxConsecWaits)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Agent.LogLevel)) {
+	case "", "error", "warn", "info", "debug":
+	default:
+		return fmt.Errorf("agent.log_level must be error, warn, info or debug (got %q)", c.Agent.LogLevel)
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Agent.RFQFillMode)) {
+	case "", "allow", "clamp", "block":
+	default:
+		return fmt.Errorf("agent.rfq_fill_mode must be allow, clamp or block (got %q)", c.Agent.RFQFillMode)
+	}
+	if c.Agent.MaxActionsPerMin < 0 {
+		return fmt.Errorf("agent.max_actions_per_minute must not be negative (got %d)", c.Agent.MaxActionsPerMin)
+	}
+	if c.Agent.SpreadBps < 0 {
+		return fmt.Errorf("agent.spread_bps must not be negative (got %g)", c.Agent.SpreadBps)
+	}
+	for asset, bps := range c.Agent.AssetSpreadBps {
+		if bps < 0 {
+			return fmt.Errorf("agent.asset_spread_bps.%s must not be negative (got %g)", asset, bps)
+		}
+	}
+	if c.Agent.TickSize < 0 {
+		return fmt.Errorf("agent.tick_size must not be negative (got %g)", c.Agent.TickSize)
+	}
+	for asset, tick := range c.Agent.AssetTickSize {
+		if tick < 0 {
+			return fmt.Errorf("agent.asset_tick_size.%s must not be negative (got %g)", asset, tick)
+		}
+	}
+	return nil
+}
+
 func Write(path string, cfg Config) error {
-	b, err := yaml.Marshal(cfg)
+	b, err := Marshal(cfg)
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(path, b, 0o600)
 }
+
+// Marshal encodes cfg in the config file format.
+func Marshal(cfg Config) ([]byte, error) {
+	return yaml.Marshal(cfg)
+}
+
+const redacted = "[redacted]"
+
+// Redacted returns a copy of c with API keys and tokens masked, for display.
+func (c Config) Redacted() Config {
+	if c.LLM.APIKey != "" {
+		c.LLM.APIKey = redacted
+	}
+	if c.Control.Token != "" {
+		c.Control.Token = redacted
+	}
+	fallbacks := make([]LLMFallback, len(c.LLM.Fallbacks))
+	for i, fallback := range c.LLM.Fallbacks {
+		if fallback.APIKey != "" {
+			fallback.APIKey = redacted
+		}
+		fallbacks[i] = fallback
+	}
+	c.LLM.Fallbacks = fallbacks
+	return c
+}
@@ -0,0 +1,79 @@
+// Package control serves the HTTP API used by `agentd serve` to inspect and
+// steer a running agent.
+package control
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"agentmarket/agent/internal/runtime"
+)
+
+// maxBodyBytes bounds request bodies; the largest is a strategy prompt.
+const maxBodyBytes = 64 << 10
+
+// NewHandler returns the control API for runner. Every request must carry
+// "Authorization: Bearer <token>"; an empty token is rejected up front so the
+// API is never served open.
+func NewHandler(runner *runtime.Runner, token string) (http.Handler, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("control token is required")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/state", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, runner.Status())
+	})
+	mux.HandleFunc("POST /v1/pause", func(w http.ResponseWriter, r *http.Request) {
+		runner.Pause()
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "pausing"})
+	})
+	mux.HandleFunc("POST /v1/resume", func(w http.ResponseWriter, r *http.Request) {
+		runner.Resume()
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "resuming"})
+	})
+	mux.HandleFunc("POST /v1/decide", func(w http.ResponseWriter, r *http.Request) {
+		runner.DecideNow()
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "decision requested"})
+	})
+	mux.HandleFunc("PUT /v1/strategy", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt *string `json:"prompt"`
+		}
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil || body.Prompt == nil {
+			writeError(w, http.StatusBadRequest, `body must be {"prompt": "..."}`)
+			return
+		}
+		runner.SetStrategyPrompt(*body.Prompt)
+		writeJSON(w, http.StatusOK, map[string]string{"strategy_prompt": strings.TrimSpace(*body.Prompt)})
+	})
+	return requireToken(token, mux), nil
+}
+
+func requireToken(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
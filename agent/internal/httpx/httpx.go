@@ -0,0 +1,122 @@
+// Package httpx builds the HTTP clients used for every outbound call
+// (indexer, registrar, LLM providers, metrics) so network settings such as
+// proxies apply uniformly.
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+var (
+	mu        sync.Mutex
+	proxyURL  string
+	transport *http.Transport
+)
+
+// SetProxy overrides HTTP_PROXY/HTTPS_PROXY with a fixed proxy URL. NO_PROXY
+// from the environment is still honored. An empty value restores the
+// environment-only behaviour.
+func SetProxy(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy url %q", raw)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	proxyURL = raw
+	transport = nil
+	return nil
+}
+
+// Transport returns the process-wide transport, building it on first use.
+func Transport() *http.Transport {
+	mu.Lock()
+	defer mu.Unlock()
+	if transport == nil {
+		transport = newTransport(proxyURL)
+	}
+	return transport
+}
+
+// NewClient returns an http.Client with the given timeout on the shared
+// transport, sending the configured User-Agent.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: uaTransport{base: Transport()}}
+}
+
+func newTransport(override string) *http.Transport {
+	cfg := httpproxy.FromEnvironment()
+	if override != "" {
+		cfg.HTTPProxy = override
+		cfg.HTTPSProxy = override
+	}
+	proxyFunc := cfg.ProxyFunc()
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	return t
+}
+
+// TLSFiles names PEM files for client-certificate (mTLS) auth. CAFile, when
+// set, replaces the system roots for verifying the server.
+type TLSFiles struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	CAFile   string `yaml:"ca_file"`
+}
+
+func (f TLSFiles) empty() bool {
+	return strings.TrimSpace(f.CertFile) == "" && strings.TrimSpace(f.KeyFile) == "" && strings.TrimSpace(f.CAFile) == ""
+}
+
+// ConfigureTLS gives client its own transport presenting the configured
+// client certificate and trusting the configured CA. It is a no-op when no
+// files are set, leaving the shared transport in place.
+func ConfigureTLS(client *http.Client, files TLSFiles) error {
+	if client == nil || files.empty() {
+		return nil
+	}
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	certFile, keyFile := strings.TrimSpace(files.CertFile), strings.TrimSpace(files.KeyFile)
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("tls cert_file and key_file must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if caFile := strings.TrimSpace(files.CAFile); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("ca file %s has no PEM certificates", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	mu.Lock()
+	t := newTransport(proxyURL)
+	mu.Unlock()
+	t.TLSClientConfig = tlsCfg
+	client.Transport = uaTransport{base: t}
+	return nil
+}
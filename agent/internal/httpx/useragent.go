@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var userAgent string
+
+// SetUserAgent sets the User-Agent sent on every request from clients built
+// here, unless a request sets its own. An empty value keeps Go's default.
+func SetUserAgent(ua string) {
+	mu.Lock()
+	defer mu.Unlock()
+	userAgent = strings.TrimSpace(ua)
+}
+
+// UserAgent formats "<base>/<version> (<agent>)", shortening the agent ID
+// to a prefix that identifies it without spelling out the full address.
+func UserAgent(base, version, agentID string) string {
+	if strings.TrimSpace(base) == "" {
+		base = "agentd"
+	}
+	ua := fmt.Sprintf("%s/%s", strings.TrimSpace(base), version)
+	agentID = strings.TrimSpace(agentID)
+	if len(agentID) > 14 {
+		agentID = agentID[:14]
+	}
+	if agentID != "" {
+		ua += " (" + agentID + ")"
+	}
+	return ua
+}
+
+func currentUserAgent() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return userAgent
+}
+
+// uaTransport stamps the configured User-Agent at send time, so clients
+// built before SetUserAgent still pick it up.
+type uaTransport struct {
+	base http.RoundTripper
+}
+
+func (t uaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ua := currentUserAgent(); ua != "" && req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", ua)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -1,19 +1,42 @@
 package indexer
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"agentmarket/agent/internal/httpx"
 )
 
 type Client struct {
 	BaseURL  string
 	HTTP     *http.Client
 	OwnerUID string
+	// CacheTTL memoizes GetAgent responses for this long (0 = always fetch).
+	CacheTTL time.Duration
+	// Denoms normalizes GetBalances from on-chain denoms to display symbols.
+	Denoms []Denom
+	// GzipMinBytes gzips POST bodies at least this large (0 = never). Only
+	// enable it for indexers that accept Content-Encoding: gzip.
+	GzipMinBytes int
+
+	cacheMu    sync.Mutex
+	agentCache map[string]cachedAgent
+}
+
+type cachedAgent struct {
+	agent     Agent
+	fetchedAt time.Time
 }
 
 type Agent struct {
@@ -26,7 +49,11 @@ type Agent struct {
 	StrategyVersion string `json:"strategy_version"`
 	StrategyPrompt  string `json:"strategy_prompt"`
 	Policy          struct {
-		AllowedTokens []string `json:"allowed_tokens"`
+		AllowedTokens         []string `json:"allowed_tokens"`
+		AllowedCounterparties []string `json:"allowed_counterparties,omitempty"`
+		DeniedCounterparties  []string `json:"denied_counterparties,omitempty"`
+		ReduceOnly            bool     `json:"reduce_only,omitempty"`
+		MintableTokens        []string `json:"mintable_tokens,omitempty"`
 	} `json:"policy"`
 }
 
@@ -61,6 +88,8 @@ type RFQ struct {
 	Status      string  `json:"status"`
 	Asset       string  `json:"asset_symbol"`
 	CreatedAt   string  `json:"created_at"`
+	// TargetAgentID, when set, addresses the RFQ to one agent for a quote.
+	TargetAgentID string `json:"target_agent_id,omitempty"`
 }
 
 type BalanceItem struct {
@@ -78,6 +107,10 @@ type DevActionRequest struct {
 	Qty         float64 `json:"qty"`
 	Side        string  `json:"side"`
 	Reason      string  `json:"reason"`
+	OfferID     string  `json:"offer_id,omitempty"`
+	RFQID       string  `json:"rfq_id,omitempty"`
+	// TTLSec asks the indexer to expire a posted offer/RFQ after this long.
+	TTLSec int `json:"ttl_sec,omitempty"`
 }
 
 type DevDecisionRequest struct {
@@ -91,12 +124,27 @@ type DevDecisionRequest struct {
 	Raw         string  `json:"raw"`
 	Status      string  `json:"status"`
 	Error       string  `json:"error"`
+	// Metadata records the market conditions behind the decision.
+	Metadata *DecisionMetadata `json:"metadata,omitempty"`
+}
+
+// DecisionMetadata is the orderbook signal (cross, strong_bid, cheap_ask or
+// watch) and prices the agent saw for the decision's asset.
+type DecisionMetadata struct {
+	Signal     string  `json:"signal"`
+	Thin       bool    `json:"thin,omitempty"`
+	LastAGC    float64 `json:"last_agc,omitempty"`
+	BestBidAGC float64 `json:"best_bid_agc,omitempty"`
+	BestAskAGC float64 `json:"best_ask_agc,omitempty"`
 }
 
 type DevHeartbeatRequest struct {
 	AgentID  string `json:"agent_id"`
 	Profile  string `json:"profile"`
 	UserAddr string `json:"user_addr"`
+	// State is one of deciding, waiting, executing, cooldown or paused.
+	State          string `json:"state,omitempty"`
+	NextDecisionAt string `json:"next_decision_at,omitempty"`
 }
 
 type Decision struct {
@@ -117,16 +165,60 @@ type AgentHistory struct {
 	Decisions []Decision `json:"decisions"`
 }
 
+// Trade is one on-chain fill the agent took part in, from its side.
+type Trade struct {
+	TradeID     string  `json:"trade_id"`
+	AgentID     string  `json:"agent_id"`
+	AssetSymbol string  `json:"asset_symbol"`
+	Side        string  `json:"side"`
+	PriceAGC    float64 `json:"price_agc"`
+	Qty         float64 `json:"qty"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// MarketStatus is the exchange-wide trading state from /v1/status.
+type MarketStatus struct {
+	// Status is open, or halted/closed while trading is paused upstream.
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	// ResumesAt, when known, is the RFC3339 time trading is expected back.
+	ResumesAt string `json:"resumes_at"`
+}
+
+// Halted reports whether the market is not accepting trades. Anything other
+// than halted or closed counts as open.
+func (s MarketStatus) Halted() bool {
+	switch strings.ToLower(strings.TrimSpace(s.Status)) {
+	case "halted", "closed":
+		return true
+	}
+	return false
+}
+
+// ErrNotFound matches, via errors.Is, indexer GETs answered with 404.
+var ErrNotFound = errors.New("indexer: not found")
+
+type statusError struct {
+	msg  string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s (status %d)", e.msg, e.code)
+}
+
+func (e *statusError) Is(target error) bool {
+	return target == ErrNotFound && e.code == http.StatusNotFound
+}
+
 func New(baseURL string, ownerUID ...string) *Client {
 	uid := ""
 	if len(ownerUID) > 0 {
 		uid = strings.TrimSpace(ownerUID[0])
 	}
 	return &Client{
-		BaseURL: strings.TrimRight(baseURL, "/"),
-		HTTP: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		HTTP:     httpx.NewClient(10 * time.Second),
 		OwnerUID: uid,
 	}
 }
@@ -141,13 +233,42 @@ func (c *Client) attachOwnerHeader(req *http.Request) {
 }
 
 func (c *Client) GetAgent(ctx context.Context, agentID string) (Agent, error) {
+	if agent, ok := c.cachedAgent(agentID); ok {
+		return agent, nil
+	}
 	var agent Agent
 	if err := c.fetchJSON(ctx, "/v1/agents/"+agentID, &agent); err != nil {
 		return Agent{}, err
 	}
+	c.storeAgent(agentID, agent)
 	return agent, nil
 }
 
+func (c *Client) cachedAgent(agentID string) (Agent, bool) {
+	if c.CacheTTL <= 0 {
+		return Agent{}, false
+	}
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	entry, ok := c.agentCache[agentID]
+	if !ok || time.Since(entry.fetchedAt) >= c.CacheTTL {
+		return Agent{}, false
+	}
+	return entry.agent, true
+}
+
+func (c *Client) storeAgent(agentID string, agent Agent) {
+	if c.CacheTTL <= 0 {
+		return
+	}
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.agentCache == nil {
+		c.agentCache = map[string]cachedAgent{}
+	}
+	c.agentCache[agentID] = cachedAgent{agent: agent, fetchedAt: time.Now()}
+}
+
 func (c *Client) GetTokens(ctx context.Context) ([]Token, error) {
 	var tokens []Token
 	if err := c.fetchJSON(ctx, "/v1/tokens", &tokens); err != nil {
@@ -156,33 +277,111 @@ func (c *Client) GetTokens(ctx context.Context) ([]Token, error) {
 	return tokens, nil
 }
 
-func (c *Client) GetOffers(ctx context.Context) ([]Offer, error) {
+// ListQuery narrows offer/RFQ listings. It is sent as query parameters and
+// also applied client-side, so it works whether or not the server filters.
+type ListQuery struct {
+	Limit  int
+	Status string
+	Assets []string
+}
+
+func (q ListQuery) encode() string {
+	values := url.Values{}
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if status := strings.TrimSpace(q.Status); status != "" {
+		values.Set("status", status)
+	}
+	for _, asset := range q.Assets {
+		if asset = strings.ToUpper(strings.TrimSpace(asset)); asset != "" {
+			values.Add("asset", asset)
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// Match reports whether an order with status and asset passes the query's
+// filters; an empty status counts as open. Limit is not applied.
+func (q ListQuery) Match(status, asset string) bool {
+	if want := strings.ToLower(strings.TrimSpace(q.Status)); want != "" {
+		got := strings.ToLower(strings.TrimSpace(status))
+		if got == "" {
+			got = "open"
+		}
+		if got != want {
+			return false
+		}
+	}
+	if len(q.Assets) == 0 {
+		return true
+	}
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	for _, want := range q.Assets {
+		if strings.ToUpper(strings.TrimSpace(want)) == asset {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) GetOffers(ctx context.Context, query ...ListQuery) ([]Offer, error) {
+	q := ListQuery{}
+	if len(query) > 0 {
+		q = query[0]
+	}
 	var offers []Offer
-	if err := c.fetchJSON(ctx, "/v1/offers", &offers); err != nil {
+	if err := c.fetchJSON(ctx, "/v1/offers"+q.encode(), &offers); err != nil {
 		return nil, err
 	}
-	return offers, nil
+	out := offers[:0]
+	for _, offer := range offers {
+		if q.Limit > 0 && len(out) >= q.Limit {
+			break
+		}
+		if q.Match(offer.Status, offer.Asset) {
+			out = append(out, offer)
+		}
+	}
+	return out, nil
 }
 
-func (c *Client) GetRFQs(ctx context.Context) ([]RFQ, error) {
+func (c *Client) GetRFQs(ctx context.Context, query ...ListQuery) ([]RFQ, error) {
+	q := ListQuery{}
+	if len(query) > 0 {
+		q = query[0]
+	}
 	var rfqs []RFQ
-	if err := c.fetchJSON(ctx, "/v1/rfqs", &rfqs); err != nil {
+	if err := c.fetchJSON(ctx, "/v1/rfqs"+q.encode(), &rfqs); err != nil {
 		return nil, err
 	}
-	return rfqs, nil
+	out := rfqs[:0]
+	for _, rfq := range rfqs {
+		if q.Limit > 0 && len(out) >= q.Limit {
+			break
+		}
+		if q.Match(rfq.Status, rfq.Asset) {
+			out = append(out, rfq)
+		}
+	}
+	return out, nil
 }
 
+// GetBalances returns addr's holdings in whole units keyed by display symbol.
+// Each mapped denom is rounded down, so affordability checks never overstate
+// a balance: 900000 uagc at exponent 6 counts as 0 AGC. GetBalanceDetails
+// keeps the exact amounts.
 func (c *Client) GetBalances(ctx context.Context, addr string) (map[string]uint64, error) {
-	var items []BalanceItem
-	if err := c.fetchJSON(ctx, "/v1/balances/"+addr, &items); err != nil {
+	balances, err := c.GetBalanceDetails(ctx, addr)
+	if err != nil {
 		return nil, err
 	}
 	out := map[string]uint64{}
-	for _, item := range items {
-		if item.Denom == "" {
-			continue
-		}
-		out[item.Denom] = item.Amount
+	for _, balance := range balances {
+		out[balance.Symbol] += balance.Whole()
 	}
 	return out, nil
 }
@@ -195,17 +394,37 @@ func (c *Client) GetAgentHistory(ctx context.Context, agentID string) (AgentHist
 	return history, nil
 }
 
+// GetAgentTrades lists the fills recorded for the agent.
+func (c *Client) GetAgentTrades(ctx context.Context, agentID string) ([]Trade, error) {
+	var trades []Trade
+	if err := c.fetchJSON(ctx, "/v1/agents/"+agentID+"/trades", &trades); err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// GetMarketStatus reports whether trading is open. Indexers without a
+// /v1/status endpoint (404) are treated as always open.
+func (c *Client) GetMarketStatus(ctx context.Context) (MarketStatus, error) {
+	var status MarketStatus
+	if err := c.fetchJSON(ctx, "/v1/status", &status); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return MarketStatus{Status: "open"}, nil
+		}
+		return MarketStatus{}, err
+	}
+	return status, nil
+}
+
 func (c *Client) PostDevAction(ctx context.Context, req DevActionRequest) error {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return err
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/dev/actions", strings.NewReader(string(body)))
+	httpReq, err := c.newPost(ctx, "/v1/dev/actions", body)
 	if err != nil {
 		return err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	c.attachOwnerHeader(httpReq)
 	resp, err := c.HTTP.Do(httpReq)
 	if err != nil {
 		return err
@@ -229,12 +448,10 @@ func (c *Client) PostDevDecision(ctx context.Context, req DevDecisionRequest) er
 	if err != nil {
 		return err
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/dev/decisions", strings.NewReader(string(body)))
+	httpReq, err := c.newPost(ctx, "/v1/dev/decisions", body)
 	if err != nil {
 		return err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	c.attachOwnerHeader(httpReq)
 	resp, err := c.HTTP.Do(httpReq)
 	if err != nil {
 		return err
@@ -258,12 +475,10 @@ func (c *Client) PostDevHeartbeat(ctx context.Context, req DevHeartbeatRequest)
 	if err != nil {
 		return err
 	}
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/dev/heartbeat", strings.NewReader(string(body)))
+	httpReq, err := c.newPost(ctx, "/v1/dev/heartbeat", body)
 	if err != nil {
 		return err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	c.attachOwnerHeader(httpReq)
 	resp, err := c.HTTP.Do(httpReq)
 	if err != nil {
 		return err
@@ -282,11 +497,42 @@ func (c *Client) PostDevHeartbeat(ctx context.Context, req DevHeartbeatRequest)
 	return nil
 }
 
+// newPost builds an authenticated JSON POST, gzipping bodies of at least
+// GzipMinBytes when that is set.
+func (c *Client) newPost(ctx context.Context, path string, body []byte) (*http.Request, error) {
+	compressed := false
+	if c.GzipMinBytes > 0 && len(body) >= c.GzipMinBytes {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+		compressed = true
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	c.attachOwnerHeader(req)
+	attachRequestID(req)
+	return req, nil
+}
+
 func (c *Client) fetchJSON(ctx context.Context, path string, out any) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
 	if err != nil {
 		return err
 	}
+	c.attachOwnerHeader(req)
+	attachRequestID(req)
 	resp, err := c.HTTP.Do(req)
 	if err != nil {
 		return err
@@ -301,7 +547,7 @@ func (c *Client) fetchJSON(ctx context.Context, path string, out any) error {
 				msg = fmt.Sprintf("%s: %s", msg, trimmed)
 			}
 		}
-		return fmt.Errorf("%s (status %d)", msg, resp.StatusCode)
+		return &statusError{msg: msg, code: resp.StatusCode}
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
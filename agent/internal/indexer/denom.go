@@ -0,0 +1,96 @@
+package indexer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Denom maps an on-chain denom to the display symbol the runtime reasons in.
+// Amounts in Denom are scaled down by 10^Exponent, so {AGC, uagc, 6} turns
+// 2500000 uagc into 2.5 AGC.
+type Denom struct {
+	Symbol   string `yaml:"symbol"`
+	Denom    string `yaml:"denom"`
+	Exponent int    `yaml:"exponent"`
+}
+
+// Balance is one exact on-chain holding under its display symbol. Amount is
+// in Denom; Exponent scales it to whole Symbol units (0 when unmapped).
+type Balance struct {
+	Symbol   string
+	Denom    string
+	Amount   uint64
+	Exponent int
+}
+
+// Whole is the balance in whole units, rounded down.
+func (b Balance) Whole() uint64 {
+	amount := b.Amount
+	for i := 0; i < b.Exponent; i++ {
+		amount /= 10
+	}
+	return amount
+}
+
+// String formats the exact balance in display units, e.g. "0.9" for
+// 900000 uagc at exponent 6.
+func (b Balance) String() string {
+	digits := strconv.FormatUint(b.Amount, 10)
+	if b.Exponent <= 0 {
+		return digits
+	}
+	if len(digits) <= b.Exponent {
+		digits = strings.Repeat("0", b.Exponent-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-b.Exponent], strings.TrimRight(digits[len(digits)-b.Exponent:], "0")
+	if frac == "" {
+		return whole
+	}
+	return whole + "." + frac
+}
+
+// DenomForSymbol returns the alias whose display symbol is symbol, the
+// reverse of the denom lookup GetBalances applies.
+func (c *Client) DenomForSymbol(symbol string) (Denom, bool) {
+	for _, alias := range c.Denoms {
+		if strings.EqualFold(strings.TrimSpace(alias.Symbol), strings.TrimSpace(symbol)) {
+			return alias, true
+		}
+	}
+	return Denom{}, false
+}
+
+// normalizeBalance maps an on-chain balance to its display symbol, keeping
+// the exact amount. Unmapped denoms pass through unchanged.
+func (c *Client) normalizeBalance(denom string, amount uint64) Balance {
+	for _, alias := range c.Denoms {
+		if !strings.EqualFold(strings.TrimSpace(alias.Denom), denom) {
+			continue
+		}
+		return Balance{
+			Symbol:   strings.ToUpper(strings.TrimSpace(alias.Symbol)),
+			Denom:    denom,
+			Amount:   amount,
+			Exponent: alias.Exponent,
+		}
+	}
+	return Balance{Symbol: denom, Denom: denom, Amount: amount}
+}
+
+// GetBalanceDetails returns addr's exact holdings, one per on-chain denom,
+// under their display symbols.
+func (c *Client) GetBalanceDetails(ctx context.Context, addr string) ([]Balance, error) {
+	var items []BalanceItem
+	if err := c.fetchJSON(ctx, "/v1/balances/"+addr, &items); err != nil {
+		return nil, err
+	}
+	out := make([]Balance, 0, len(items))
+	for _, item := range items {
+		if item.Denom == "" {
+			continue
+		}
+		out = append(out, c.normalizeBalance(item.Denom, item.Amount))
+	}
+	return out, nil
+}
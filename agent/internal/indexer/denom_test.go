@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBalancesWithDenoms(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/balances/agent-1" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]BalanceItem{
+			{Denom: "uagc", Amount: 900000},
+			{Denom: "ufoo", Amount: 2500000},
+			{Denom: "BAR", Amount: 7},
+		})
+	}))
+	defer srv.Close()
+	client := New(srv.URL, "")
+	client.Denoms = []Denom{
+		{Symbol: "agc", Denom: "uagc", Exponent: 6},
+		{Symbol: "FOO", Denom: "ufoo", Exponent: 6},
+	}
+
+	details, err := client.GetBalanceDetails(context.Background(), "agent-1")
+	if err != nil {
+		t.Fatalf("balance details: %v", err)
+	}
+	exact := map[string]string{}
+	for _, balance := range details {
+		exact[balance.Symbol] = balance.String()
+	}
+	for symbol, want := range map[string]string{"AGC": "0.9", "FOO": "2.5", "BAR": "7"} {
+		if exact[symbol] != want {
+			t.Fatalf("exact %s balance %q, want %q", symbol, exact[symbol], want)
+		}
+	}
+
+	whole, err := client.GetBalances(context.Background(), "agent-1")
+	if err != nil {
+		t.Fatalf("balances: %v", err)
+	}
+	// Whole units round down: a balance below one unit counts as none.
+	for symbol, want := range map[string]uint64{"AGC": 0, "FOO": 2, "BAR": 7} {
+		if got, ok := whole[symbol]; !ok || got != want {
+			t.Fatalf("whole %s balance %d (present %t), want %d", symbol, got, ok, want)
+		}
+	}
+
+	denom, ok := client.DenomForSymbol("AGC")
+	if !ok || denom.Denom != "uagc" || denom.Exponent != 6 {
+		t.Fatalf("DenomForSymbol(AGC) = %+v, %t; want uagc at exponent 6", denom, ok)
+	}
+	if _, ok := client.DenomForSymbol("BAR"); ok {
+		t.Fatal("DenomForSymbol(BAR) found an alias for an unmapped symbol")
+	}
+}
+
+func TestBalanceString(t *testing.T) {
+	for _, tc := range []struct {
+		balance Balance
+		want    string
+	}{
+		{Balance{Amount: 42}, "42"},
+		{Balance{Amount: 1, Exponent: 6}, "0.000001"},
+		{Balance{Amount: 0, Exponent: 6}, "0"},
+		{Balance{Amount: 3000000, Exponent: 6}, "3"},
+		{Balance{Amount: 3050000, Exponent: 6}, "3.05"},
+	} {
+		if got := tc.balance.String(); got != tc.want {
+			t.Fatalf("%+v formats as %q, want %q", tc.balance, got, tc.want)
+		}
+	}
+}
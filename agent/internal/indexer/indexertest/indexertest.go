@@ -0,0 +1,274 @@
+// Package indexertest provides an in-memory indexer.MarketData for tests of
+// the runtime and CLI flows that would otherwise need a live indexer.
+package indexertest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// Indexer serves preloaded market data and records everything posted to it.
+// It is safe for concurrent use; the zero value is ready to use.
+type Indexer struct {
+	mu         sync.Mutex
+	agents     map[string]indexer.Agent
+	histories  map[string]indexer.AgentHistory
+	trades     map[string][]indexer.Trade
+	balances   map[string]map[string]uint64
+	tokens     []indexer.Token
+	offers     []indexer.Offer
+	rfqs       []indexer.RFQ
+	market     indexer.MarketStatus
+	err        error
+	actions    []indexer.DevActionRequest
+	decisions  []indexer.DevDecisionRequest
+	heartbeats []indexer.DevHeartbeatRequest
+}
+
+var _ indexer.MarketData = (*Indexer)(nil)
+
+// New returns an empty Indexer.
+func New() *Indexer {
+	return &Indexer{}
+}
+
+// SetAgent registers agent under its ID for GetAgent.
+func (s *Indexer) SetAgent(agent indexer.Agent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.agents == nil {
+		s.agents = map[string]indexer.Agent{}
+	}
+	s.agents[agent.AgentID] = agent
+}
+
+func (s *Indexer) SetTokens(tokens ...indexer.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = append([]indexer.Token(nil), tokens...)
+}
+
+func (s *Indexer) SetOffers(offers ...indexer.Offer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offers = append([]indexer.Offer(nil), offers...)
+}
+
+func (s *Indexer) SetRFQs(rfqs ...indexer.RFQ) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rfqs = append([]indexer.RFQ(nil), rfqs...)
+}
+
+// SetBalances sets the balances GetBalances returns for addr, keyed by
+// display symbol.
+func (s *Indexer) SetBalances(addr string, balances map[string]uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.balances == nil {
+		s.balances = map[string]map[string]uint64{}
+	}
+	s.balances[addr] = copyBalances(balances)
+}
+
+func (s *Indexer) SetHistory(agentID string, history indexer.AgentHistory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.histories == nil {
+		s.histories = map[string]indexer.AgentHistory{}
+	}
+	s.histories[agentID] = history
+}
+
+func (s *Indexer) SetTrades(agentID string, trades ...indexer.Trade) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.trades == nil {
+		s.trades = map[string][]indexer.Trade{}
+	}
+	s.trades[agentID] = append([]indexer.Trade(nil), trades...)
+}
+
+// SetMarketStatus sets what GetMarketStatus returns (default: open).
+func (s *Indexer) SetMarketStatus(status indexer.MarketStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.market = status
+}
+
+// SetError makes every call fail with err until it is cleared with nil.
+// Failed posts are not recorded.
+func (s *Indexer) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Actions returns the actions posted so far, oldest first.
+func (s *Indexer) Actions() []indexer.DevActionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]indexer.DevActionRequest(nil), s.actions...)
+}
+
+// Decisions returns the decisions posted so far, oldest first.
+func (s *Indexer) Decisions() []indexer.DevDecisionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]indexer.DevDecisionRequest(nil), s.decisions...)
+}
+
+// Heartbeats returns the heartbeats posted so far, oldest first.
+func (s *Indexer) Heartbeats() []indexer.DevHeartbeatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]indexer.DevHeartbeatRequest(nil), s.heartbeats...)
+}
+
+func (s *Indexer) GetAgent(ctx context.Context, agentID string) (indexer.Agent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return indexer.Agent{}, s.err
+	}
+	agent, ok := s.agents[agentID]
+	if !ok {
+		return indexer.Agent{}, fmt.Errorf("agent %s not found", agentID)
+	}
+	return agent, nil
+}
+
+func (s *Indexer) GetTokens(ctx context.Context) ([]indexer.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return append([]indexer.Token(nil), s.tokens...), nil
+}
+
+func (s *Indexer) GetOffers(ctx context.Context, query ...indexer.ListQuery) ([]indexer.Offer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	q := firstQuery(query)
+	out := []indexer.Offer{}
+	for _, offer := range s.offers {
+		if q.Limit > 0 && len(out) >= q.Limit {
+			break
+		}
+		if q.Match(offer.Status, offer.Asset) {
+			out = append(out, offer)
+		}
+	}
+	return out, nil
+}
+
+func (s *Indexer) GetRFQs(ctx context.Context, query ...indexer.ListQuery) ([]indexer.RFQ, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	q := firstQuery(query)
+	out := []indexer.RFQ{}
+	for _, rfq := range s.rfqs {
+		if q.Limit > 0 && len(out) >= q.Limit {
+			break
+		}
+		if q.Match(rfq.Status, rfq.Asset) {
+			out = append(out, rfq)
+		}
+	}
+	return out, nil
+}
+
+func (s *Indexer) GetBalances(ctx context.Context, addr string) (map[string]uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return copyBalances(s.balances[addr]), nil
+}
+
+func (s *Indexer) GetAgentHistory(ctx context.Context, agentID string) (indexer.AgentHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return indexer.AgentHistory{}, s.err
+	}
+	return s.histories[agentID], nil
+}
+
+func (s *Indexer) GetAgentTrades(ctx context.Context, agentID string) ([]indexer.Trade, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return append([]indexer.Trade(nil), s.trades[agentID]...), nil
+}
+
+func (s *Indexer) GetMarketStatus(ctx context.Context) (indexer.MarketStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return indexer.MarketStatus{}, s.err
+	}
+	if s.market.Status == "" {
+		return indexer.MarketStatus{Status: "open"}, nil
+	}
+	return s.market, nil
+}
+
+func (s *Indexer) PostDevAction(ctx context.Context, req indexer.DevActionRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.actions = append(s.actions, req)
+	return nil
+}
+
+func (s *Indexer) PostDevDecision(ctx context.Context, req indexer.DevDecisionRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.decisions = append(s.decisions, req)
+	return nil
+}
+
+func (s *Indexer) PostDevHeartbeat(ctx context.Context, req indexer.DevHeartbeatRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.heartbeats = append(s.heartbeats, req)
+	return nil
+}
+
+func firstQuery(query []indexer.ListQuery) indexer.ListQuery {
+	if len(query) > 0 {
+		return query[0]
+	}
+	return indexer.ListQuery{}
+}
+
+func copyBalances(balances map[string]uint64) map[string]uint64 {
+	out := make(map[string]uint64, len(balances))
+	for symbol, amount := range balances {
+		out[strings.ToUpper(strings.TrimSpace(symbol))] = amount
+	}
+	return out
+}
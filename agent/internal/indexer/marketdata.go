@@ -0,0 +1,22 @@
+package indexer
+
+import "context"
+
+// MarketData is the indexer surface the runtime reads market state from and
+// posts agent actions, decisions and heartbeats to. *Client implements it;
+// indexertest.Indexer is an in-memory stand-in for tests.
+type MarketData interface {
+	GetAgent(ctx context.Context, agentID string) (Agent, error)
+	GetTokens(ctx context.Context) ([]Token, error)
+	GetOffers(ctx context.Context, query ...ListQuery) ([]Offer, error)
+	GetRFQs(ctx context.Context, query ...ListQuery) ([]RFQ, error)
+	GetBalances(ctx context.Context, addr string) (map[string]uint64, error)
+	GetAgentHistory(ctx context.Context, agentID string) (AgentHistory, error)
+	GetAgentTrades(ctx context.Context, agentID string) ([]Trade, error)
+	GetMarketStatus(ctx context.Context) (MarketStatus, error)
+	PostDevAction(ctx context.Context, req DevActionRequest) error
+	PostDevDecision(ctx context.Context, req DevDecisionRequest) error
+	PostDevHeartbeat(ctx context.Context, req DevHeartbeatRequest) error
+}
+
+var _ MarketData = (*Client)(nil)
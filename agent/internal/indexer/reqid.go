@@ -0,0 +1,26 @@
+package indexer
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id; client calls made with it send the
+// id in an X-Request-ID header so one decision can be traced end to end.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the id set by WithRequestID, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func attachRequestID(req *http.Request) {
+	if id := RequestID(req.Context()); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+}
@@ -0,0 +1,147 @@
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Key names used by agentd.
+const (
+	UserKey  = "user"
+	AgentKey = "agent"
+)
+
+// Backend stores keys by name. Open picks one from a key store location.
+type Backend interface {
+	Load(name string) (StoredKey, error)
+	Save(name string, key StoredKey) error
+	// Backup stores a copy of key under a name derived from stamp and
+	// returns where it went.
+	Backup(name string, key StoredKey, stamp string) (string, error)
+	// String describes the location for messages.
+	String() string
+}
+
+// Open returns the backend for a key store location:
+//
+//	file:///path or a plain path  JSON files <name>.json in that directory
+//	keyring://[service]           the OS keychain (service defaults to agentd)
+//	env://[PREFIX]                read-only, from <PREFIX><NAME>_KEY (prefix defaults to AGENTD_)
+func Open(store string) (Backend, error) {
+	store = strings.TrimSpace(store)
+	scheme, rest, found := strings.Cut(store, "://")
+	if !found {
+		scheme, rest = "file", store
+	}
+	switch strings.ToLower(scheme) {
+	case "file":
+		if rest == "" {
+			return nil, fmt.Errorf("key store: file:// needs a directory")
+		}
+		return fileBackend{dir: rest}, nil
+	case "keyring":
+		return openKeyring(rest)
+	case "env":
+		if rest == "" {
+			rest = "AGENTD_"
+		}
+		return envBackend{prefix: rest}, nil
+	default:
+		return nil, fmt.Errorf("key store: unknown scheme %q (want file, keyring or env)", scheme)
+	}
+}
+
+// LockDir is where the run lock for store lives: the key directory for
+// file stores, fallback for the others, which have no directory of their own.
+func LockDir(store, fallback string) string {
+	if backend, err := Open(store); err == nil {
+		if files, ok := backend.(fileBackend); ok {
+			return files.dir
+		}
+	}
+	return fallback
+}
+
+// fileBackend keeps each key as <dir>/<name>.json, readable only by the owner.
+type fileBackend struct {
+	dir string
+}
+
+func (b fileBackend) path(name string) string {
+	return filepath.Join(b.dir, name+".json")
+}
+
+func (b fileBackend) Load(name string) (StoredKey, error) {
+	bz, err := os.ReadFile(b.path(name))
+	if err != nil {
+		return StoredKey{}, err
+	}
+	return decodeKey(bz)
+}
+
+func (b fileBackend) Save(name string, key StoredKey) error {
+	return writeKeyFile(b.path(name), key)
+}
+
+func (b fileBackend) Backup(name string, key StoredKey, stamp string) (string, error) {
+	backup := fmt.Sprintf("%s.%s.bak", b.path(name), stamp)
+	return backup, writeKeyFile(backup, key)
+}
+
+func (b fileBackend) String() string {
+	return b.dir
+}
+
+// writeKeyFile replaces path atomically so a failed write never leaves a
+// truncated key behind.
+func writeKeyFile(path string, key StoredKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	bz, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bz, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// envBackend reads keys from the environment for containers and other
+// ephemeral deployments. <PREFIX><NAME>_KEY holds either a hex private key
+// or the JSON a file store would contain.
+type envBackend struct {
+	prefix string
+}
+
+func (b envBackend) variable(name string) string {
+	return b.prefix + strings.ToUpper(name) + "_KEY"
+}
+
+func (b envBackend) Load(name string) (StoredKey, error) {
+	value := strings.TrimSpace(os.Getenv(b.variable(name)))
+	if value == "" {
+		return StoredKey{}, fmt.Errorf("%s is not set", b.variable(name))
+	}
+	if strings.HasPrefix(value, "{") {
+		return decodeKey([]byte(value))
+	}
+	return FromPrivKeyHex(name, value)
+}
+
+func (b envBackend) Save(name string, key StoredKey) error {
+	return fmt.Errorf("env key store is read-only; set %s to the key's private key hex", b.variable(name))
+}
+
+func (b envBackend) Backup(name string, key StoredKey, stamp string) (string, error) {
+	return "", fmt.Errorf("env key store is read-only; rotate by replacing %s", b.variable(name))
+}
+
+func (b envBackend) String() string {
+	return "env://" + b.prefix
+}
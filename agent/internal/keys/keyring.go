@@ -0,0 +1,77 @@
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/99designs/keyring"
+)
+
+// keyringBackend keeps keys in the OS keychain (macOS Keychain, Windows
+// Credential Manager, Secret Service or KWallet), one item per name.
+type keyringBackend struct {
+	service string
+	ring    keyring.Keyring
+}
+
+func openKeyring(service string) (Backend, error) {
+	if service == "" {
+		service = "agentd"
+	}
+	ring, err := keyring.Open(keyring.Config{
+		ServiceName: service,
+		// Only OS-managed stores; keyring's own file backend would prompt
+		// for a passphrase and puts keys on disk anyway.
+		AllowedBackends: []keyring.BackendType{
+			keyring.KeychainBackend,
+			keyring.WinCredBackend,
+			keyring.SecretServiceBackend,
+			keyring.KWalletBackend,
+		},
+		KeychainTrustApplication: true,
+		LibSecretCollectionName:  "login",
+		KWalletAppID:             service,
+		KWalletFolder:            service,
+		WinCredPrefix:            service,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("key store: open OS keyring: %w", err)
+	}
+	return keyringBackend{service: service, ring: ring}, nil
+}
+
+func (b keyringBackend) Load(name string) (StoredKey, error) {
+	item, err := b.ring.Get(name)
+	if err != nil {
+		return StoredKey{}, err
+	}
+	return decodeKey(item.Data)
+}
+
+func (b keyringBackend) Save(name string, key StoredKey) error {
+	return b.set(name, key)
+}
+
+func (b keyringBackend) Backup(name string, key StoredKey, stamp string) (string, error) {
+	backup := fmt.Sprintf("%s.%s.bak", name, stamp)
+	if err := b.set(backup, key); err != nil {
+		return "", err
+	}
+	return b.String() + "/" + backup, nil
+}
+
+func (b keyringBackend) set(name string, key StoredKey) error {
+	bz, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return b.ring.Set(keyring.Item{
+		Key:   name,
+		Data:  bz,
+		Label: b.service + " " + name + " key",
+	})
+}
+
+func (b keyringBackend) String() string {
+	return "keyring://" + b.service
+}
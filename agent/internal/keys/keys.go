@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
+// Now timestamps generated keys and rotation backups. Tests may replace it
+// with a fixed clock.
+var Now = time.Now
+
 type StoredKey struct {
 	Name       string `json:"name"`
 	Address    string `json:"address"`
@@ -20,50 +25,47 @@ type StoredKey struct {
 	CreatedAt  string `json:"created_at"`
 }
 
-func EnsureKey(path, name string) (StoredKey, bool, error) {
-	if key, err := Load(path); err == nil {
+// EnsureKey loads the named key from backend, generating and saving one if
+// it does not exist yet. created reports whether a new key was made.
+func EnsureKey(backend Backend, name string) (key StoredKey, created bool, err error) {
+	if key, err := backend.Load(name); err == nil {
 		return key, false, nil
 	}
-	key, err := Generate(name)
+	key, err = Generate(name)
 	if err != nil {
 		return StoredKey{}, false, err
 	}
-	if err := Save(path, key); err != nil {
+	if err := backend.Save(name, key); err != nil {
 		return StoredKey{}, false, err
 	}
 	return key, true, nil
 }
 
 func Generate(name string) (StoredKey, error) {
-	priv := secp256k1.GenPrivKey()
-	pub := priv.PubKey()
-	addr := sdk.AccAddress(pub.Address()).String()
+	return fromPrivKey(name, secp256k1.GenPrivKey()), nil
+}
+
+// FromPrivKeyHex rebuilds a key from its hex-encoded private key.
+func FromPrivKeyHex(name, privHex string) (StoredKey, error) {
+	bz, err := hex.DecodeString(strings.TrimSpace(privHex))
+	if err != nil || len(bz) != secp256k1.PrivKeySize {
+		return StoredKey{}, fmt.Errorf("invalid private key: want %d hex-encoded bytes", secp256k1.PrivKeySize)
+	}
+	return fromPrivKey(name, &secp256k1.PrivKey{Key: bz}), nil
+}
 
+func fromPrivKey(name string, priv *secp256k1.PrivKey) StoredKey {
+	pub := priv.PubKey()
 	return StoredKey{
 		Name:       name,
-		Address:    addr,
+		Address:    sdk.AccAddress(pub.Address()).String(),
 		PubKeyHex:  hex.EncodeToString(pub.Bytes()),
 		PrivKeyHex: hex.EncodeToString(priv.Bytes()),
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
-	}, nil
-}
-
-func Save(path string, key StoredKey) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
-		return err
-	}
-	bz, err := json.MarshalIndent(key, "", "  ")
-	if err != nil {
-		return err
+		CreatedAt:  Now().UTC().Format(time.RFC3339),
 	}
-	return os.WriteFile(path, bz, 0o600)
 }
 
-func Load(path string) (StoredKey, error) {
-	bz, err := os.ReadFile(path)
-	if err != nil {
-		return StoredKey{}, err
-	}
+func decodeKey(bz []byte) (StoredKey, error) {
 	var key StoredKey
 	if err := json.Unmarshal(bz, &key); err != nil {
 		return StoredKey{}, err
@@ -74,10 +76,53 @@ func Load(path string) (StoredKey, error) {
 	return key, nil
 }
 
-func DefaultUserKeyPath(base string) string {
-	return filepath.Join(base, "user.json")
+// Rotate replaces the named key with a freshly generated one, keeping the
+// previous key as a timestamped backup in the same backend.
+func Rotate(backend Backend, name string) (old, fresh StoredKey, backup string, err error) {
+	old, err = backend.Load(name)
+	if err != nil {
+		return StoredKey{}, StoredKey{}, "", err
+	}
+	backup, err = backend.Backup(name, old, Now().UTC().Format("20060102T150405Z"))
+	if err != nil {
+		return StoredKey{}, StoredKey{}, "", err
+	}
+	fresh, err = Generate(name)
+	if err != nil {
+		return StoredKey{}, StoredKey{}, "", err
+	}
+	if err := backend.Save(name, fresh); err != nil {
+		return StoredKey{}, StoredKey{}, "", err
+	}
+	return old, fresh, backup, nil
+}
+
+// AcquireRunLock marks the key store as in use by a running agent. The
+// returned release func removes the lock; ok is false if another process
+// already holds it.
+func AcquireRunLock(base string) (release func(), ok bool, err error) {
+	path := RunLockPath(base)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return func() {}, false, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return func() {}, false, nil
+		}
+		return func() {}, false, err
+	}
+	_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+	_ = f.Close()
+	return func() { _ = os.Remove(path) }, true, nil
+}
+
+// RunLocked reports whether an agent run currently holds the key store lock.
+func RunLocked(base string) bool {
+	_, err := os.Stat(RunLockPath(base))
+	return err == nil
 }
 
-func DefaultAgentKeyPath(base string) string {
-	return filepath.Join(base, "agent.json")
+func RunLockPath(base string) string {
+	return filepath.Join(base, "agent.lock")
 }
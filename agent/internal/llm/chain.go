@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// chainClient tries each client in order until one produces a response.
+// Provider and Model report whichever client served the most recent call, so
+// the runtime's decision logs name the provider that actually answered.
+type chainClient struct {
+	clients []Client
+
+	mu     sync.Mutex
+	served Client
+}
+
+func newChain(clients []Client) Client {
+	if len(clients) == 1 {
+		return clients[0]
+	}
+	return &chainClient{clients: clients, served: clients[0]}
+}
+
+func (c *chainClient) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	return c.GenerateWithOptions(ctx, prompt, Options{})
+}
+
+func (c *chainClient) GenerateWithOptions(ctx context.Context, prompt Prompt, opts Options) (string, error) {
+	var errs []error
+	for _, client := range c.clients {
+		text, err := client.GenerateWithOptions(ctx, prompt, opts)
+		if err == nil {
+			c.mu.Lock()
+			c.served = client
+			c.mu.Unlock()
+			return text, nil
+		}
+		errs = append(errs, fmt.Errorf("%s/%s: %w", client.Provider(), client.Model(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+// Ping succeeds when at least one client in the chain is usable.
+func (c *chainClient) Ping(ctx context.Context) error {
+	var errs []error
+	for _, client := range c.clients {
+		err := client.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s/%s: %w", client.Provider(), client.Model(), err))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *chainClient) Provider() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.served.Provider()
+}
+
+func (c *chainClient) Model() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.served.Model()
+}
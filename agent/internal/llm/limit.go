@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Limiter caps concurrent in-flight Generate calls across every client it
+// wraps, so several agents sharing one provider key stay under rate limits.
+type Limiter struct {
+	slots chan struct{}
+}
+
+var (
+	sharedLimitersMu sync.Mutex
+	sharedLimiters   = map[string]*Limiter{}
+)
+
+func NewLimiter(maxConcurrent int) *Limiter {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &Limiter{slots: make(chan struct{}, maxConcurrent)}
+}
+
+// sharedLimiter returns the process-wide limiter for a provider, creating it
+// on first use; later callers share the first caller's capacity.
+func sharedLimiter(provider string, maxConcurrent int) *Limiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+	if l, ok := sharedLimiters[provider]; ok {
+		return l
+	}
+	l := NewLimiter(maxConcurrent)
+	sharedLimiters[provider] = l
+	return l
+}
+
+// Wrap returns a Client whose Generate calls wait for a free slot.
+func (l *Limiter) Wrap(client Client) Client {
+	if client == nil {
+		return nil
+	}
+	return &limitedClient{Client: client, limiter: l}
+}
+
+func (l *Limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) release() {
+	<-l.slots
+}
+
+type limitedClient struct {
+	Client
+	limiter *Limiter
+}
+
+func (c *limitedClient) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	return c.GenerateWithOptions(ctx, prompt, Options{})
+}
+
+func (c *limitedClient) GenerateWithOptions(ctx context.Context, prompt Prompt, opts Options) (string, error) {
+	if err := c.limiter.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer c.limiter.release()
+	return c.Client.GenerateWithOptions(ctx, prompt, opts)
+}
@@ -14,8 +14,20 @@ type Prompt struct {
 	User   string
 }
 
+// Options overrides a client's configured sampling settings for one call.
+// Zero fields keep the configured value.
+type Options struct {
+	Temperature     *float64
+	MaxOutputTokens int
+	Stop            []string
+}
+
 type Client interface {
+	// Generate is GenerateWithOptions with no overrides.
 	Generate(ctx context.Context, prompt Prompt) (string, error)
+	GenerateWithOptions(ctx context.Context, prompt Prompt, opts Options) (string, error)
+	// Ping checks the provider is reachable and the credentials/model are valid.
+	Ping(ctx context.Context) error
 	Provider() string
 	Model() string
 }
@@ -25,33 +37,143 @@ type Config struct {
 	Model           string
 	BaseURL         string
 	APIKey          string
+	APIKeyFile      string
 	Temperature     float64
 	MaxOutputTokens int
 	TimeoutSeconds  int
+	MaxConcurrent   int
+	APIStyle        string
+	ReasoningEffort string
+	AzureDeployment string
+	AzureAPIVersion string
+	MockFile        string
+	MockCycle       bool
+	// Fallbacks are tried in order when this provider's Generate fails.
+	Fallbacks []Config
+}
+
+// temperature returns the override if set, else configured.
+func (o Options) temperature(configured float64) float64 {
+	if o.Temperature != nil {
+		return *o.Temperature
+	}
+	return configured
+}
+
+// maxOutputTokens returns the override if set, else configured.
+func (o Options) maxOutputTokens(configured int) int {
+	if o.MaxOutputTokens > 0 {
+		return o.MaxOutputTokens
+	}
+	return configured
 }
 
+const (
+	defaultAzureAPIVersion = "2025-03-01-preview"
+	defaultOpenAIBaseURL   = "https://api.openai.com/v1"
+)
+
 func New(cfg Config) (Client, error) {
+	primary, err := newLimited(cfg)
+	if err != nil || primary == nil {
+		return nil, err
+	}
+	clients := []Client{primary}
+	for i, fallback := range cfg.Fallbacks {
+		client, err := newLimited(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("llm fallback %d: %w", i+1, err)
+		}
+		if client != nil {
+			clients = append(clients, client)
+		}
+	}
+	return newChain(clients), nil
+}
+
+func newLimited(cfg Config) (Client, error) {
+	client, err := newClient(cfg)
+	if err != nil || client == nil {
+		return nil, err
+	}
+	if cfg.MaxConcurrent > 0 {
+		client = sharedLimiter(client.Provider(), cfg.MaxConcurrent).Wrap(client)
+	}
+	return client, nil
+}
+
+func newClient(cfg Config) (Client, error) {
 	provider := strings.ToLower(strings.TrimSpace(cfg.Provider))
 	if provider == "" {
 		return nil, nil
 	}
 
+	apiStyle, err := resolveAPIStyle(cfg.APIStyle)
+	if err != nil {
+		return nil, err
+	}
+	effort := strings.ToLower(strings.TrimSpace(cfg.ReasoningEffort))
+	switch effort {
+	case "", "low", "medium", "high":
+	default:
+		return nil, fmt.Errorf("invalid reasoning effort: %s (want low, medium or high)", cfg.ReasoningEffort)
+	}
+
 	switch provider {
 	case "openai":
-		apiKey := strings.TrimSpace(cfg.APIKey)
-		if apiKey == "" {
-			apiKey = strings.TrimSpace(os.Getenv("OPENAI_API_KEY"))
+		apiKey, err := resolveAPIKey(cfg, "OPENAI_API_KEY")
+		if err != nil {
+			return nil, err
 		}
-		if apiKey == "" {
+		baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+		if baseURL == "" {
+			baseURL = defaultOpenAIBaseURL
+		}
+		// Self-hosted OpenAI-compatible servers usually run without auth.
+		if apiKey == "" && baseURL == defaultOpenAIBaseURL {
 			return nil, errors.New("openai selected but no API key provided (OPENAI_API_KEY)")
 		}
 		model := strings.TrimSpace(cfg.Model)
 		if model == "" {
 			return nil, errors.New("openai selected but no model configured")
 		}
+		timeout := cfg.TimeoutSeconds
+		if timeout <= 0 {
+			timeout = 15
+		}
+		return &openAIClient{
+			baseURL:         baseURL,
+			apiKey:          apiKey,
+			model:           model,
+			temperature:     cfg.Temperature,
+			maxOutputTokens: cfg.MaxOutputTokens,
+			timeout:         time.Duration(timeout) * time.Second,
+			apiStyle:        apiStyle,
+			reasoningEffort: effort,
+		}, nil
+	case "azure-openai", "azure_openai", "azure":
+		apiKey, err := resolveAPIKey(cfg, "AZURE_OPENAI_API_KEY")
+		if err != nil {
+			return nil, err
+		}
+		if apiKey == "" {
+			return nil, errors.New("azure-openai selected but no API key provided (AZURE_OPENAI_API_KEY)")
+		}
 		baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
 		if baseURL == "" {
-			baseURL = "https://api.openai.com/v1"
+			return nil, errors.New("azure-openai selected but no base URL configured (https://<resource>.openai.azure.com)")
+		}
+		deployment := strings.TrimSpace(cfg.AzureDeployment)
+		if deployment == "" {
+			return nil, errors.New("azure-openai selected but no deployment configured")
+		}
+		apiVersion := strings.TrimSpace(cfg.AzureAPIVersion)
+		if apiVersion == "" {
+			apiVersion = defaultAzureAPIVersion
+		}
+		model := strings.TrimSpace(cfg.Model)
+		if model == "" {
+			model = deployment
 		}
 		timeout := cfg.TimeoutSeconds
 		if timeout <= 0 {
@@ -64,6 +186,10 @@ func New(cfg Config) (Client, error) {
 			temperature:     cfg.Temperature,
 			maxOutputTokens: cfg.MaxOutputTokens,
 			timeout:         time.Duration(timeout) * time.Second,
+			azureDeployment: deployment,
+			azureAPIVersion: apiVersion,
+			apiStyle:        apiStyle,
+			reasoningEffort: effort,
 		}, nil
 	case "ollama":
 		model := strings.TrimSpace(cfg.Model)
@@ -85,7 +211,48 @@ func New(cfg Config) (Client, error) {
 			maxOutputTokens: cfg.MaxOutputTokens,
 			timeout:         time.Duration(timeout) * time.Second,
 		}, nil
+	case "mock":
+		path := strings.TrimSpace(cfg.MockFile)
+		if path == "" {
+			return nil, errors.New("mock selected but no mock file configured")
+		}
+		responses, err := loadMockResponses(path)
+		if err != nil {
+			return nil, err
+		}
+		return &mockClient{path: path, responses: responses, cycle: cfg.MockCycle}, nil
 	default:
 		return nil, fmt.Errorf("unknown llm provider: %s", provider)
 	}
 }
+
+func resolveAPIStyle(style string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(style)) {
+	case "", apiStyleResponses:
+		return apiStyleResponses, nil
+	case apiStyleChatCompletions, "chat-completions", "chat":
+		return apiStyleChatCompletions, nil
+	default:
+		return "", fmt.Errorf("unknown llm api style: %s (want responses or chat_completions)", style)
+	}
+}
+
+// resolveAPIKey prefers APIKeyFile (e.g. a mounted secret) over the inline
+// key, falling back to the provider's environment variable.
+func resolveAPIKey(cfg Config, envVar string) (string, error) {
+	if path := strings.TrimSpace(cfg.APIKeyFile); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read api key file: %w", err)
+		}
+		key := strings.TrimSpace(string(b))
+		if key == "" {
+			return "", fmt.Errorf("api key file %s is empty", path)
+		}
+		return key, nil
+	}
+	if key := strings.TrimSpace(cfg.APIKey); key != "" {
+		return key, nil
+	}
+	return strings.TrimSpace(os.Getenv(envVar)), nil
+}
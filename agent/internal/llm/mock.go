@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// mockClient replays canned responses from a file in order, for
+// reproducible runs and runtime regression tests.
+type mockClient struct {
+	path      string
+	responses []string
+	cycle     bool
+
+	mu   sync.Mutex
+	next int
+}
+
+// loadMockResponses reads either a JSON array of action objects or one JSON
+// object per line.
+func loadMockResponses(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("mock file %s is empty", path)
+	}
+	out := []string{}
+	if trimmed[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("mock file %s: %w", path, err)
+		}
+		for _, item := range items {
+			out = append(out, strings.TrimSpace(string(item)))
+		}
+	} else {
+		for _, line := range strings.Split(string(trimmed), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			out = append(out, line)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("mock file %s has no responses", path)
+	}
+	return out, nil
+}
+
+func (c *mockClient) Provider() string {
+	return "mock"
+}
+
+func (c *mockClient) Model() string {
+	return c.path
+}
+
+func (c *mockClient) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (c *mockClient) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	return c.GenerateWithOptions(ctx, prompt, Options{})
+}
+
+// GenerateWithOptions ignores opts: canned responses have nothing to sample.
+func (c *mockClient) GenerateWithOptions(ctx context.Context, prompt Prompt, opts Options) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next >= len(c.responses) {
+		if !c.cycle {
+			return "", fmt.Errorf("mock responses exhausted after %d calls", len(c.responses))
+		}
+		c.next = 0
+	}
+	text := c.responses[c.next]
+	c.next++
+	return text, nil
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"agentmarket/agent/internal/httpx"
 )
 
 type ollamaClient struct {
@@ -34,7 +36,46 @@ func (c *ollamaClient) Model() string {
 	return c.model
 }
 
+// Ping lists local models via /api/tags and checks the configured one is pulled.
+func (c *ollamaClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return err
+	}
+	httpClient := httpx.NewClient(c.timeout)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ollama error (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return err
+	}
+	for _, m := range tags.Models {
+		if m.Name == c.model || strings.TrimSuffix(m.Name, ":latest") == c.model {
+			return nil
+		}
+	}
+	return fmt.Errorf("ollama model %s not found; run: ollama pull %s", c.model, c.model)
+}
+
 func (c *ollamaClient) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	return c.GenerateWithOptions(ctx, prompt, Options{})
+}
+
+func (c *ollamaClient) GenerateWithOptions(ctx context.Context, prompt Prompt, opts Options) (string, error) {
 	messages := []map[string]string{}
 	if strings.TrimSpace(prompt.System) != "" {
 		messages = append(messages, map[string]string{
@@ -59,11 +100,14 @@ func (c *ollamaClient) Generate(ctx context.Context, prompt Prompt) (string, err
 	}
 
 	options := map[string]any{}
-	if c.temperature > 0 {
-		options["temperature"] = c.temperature
+	if temperature := opts.temperature(c.temperature); temperature > 0 || opts.Temperature != nil {
+		options["temperature"] = temperature
+	}
+	if maxTokens := opts.maxOutputTokens(c.maxOutputTokens); maxTokens > 0 {
+		options["num_predict"] = maxTokens
 	}
-	if c.maxOutputTokens > 0 {
-		options["num_predict"] = c.maxOutputTokens
+	if len(opts.Stop) > 0 {
+		options["stop"] = opts.Stop
 	}
 	if len(options) > 0 {
 		payload["options"] = options
@@ -80,7 +124,7 @@ func (c *ollamaClient) Generate(ctx context.Context, prompt Prompt) (string, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	httpClient := &http.Client{Timeout: c.timeout}
+	httpClient := httpx.NewClient(c.timeout)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", err
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"agentmarket/agent/internal/httpx"
 )
 
 type openAIClient struct {
@@ -18,9 +21,25 @@ type openAIClient struct {
 	temperature     float64
 	maxOutputTokens int
 	timeout         time.Duration
+	// Azure deployments use a per-deployment URL and an api-key header.
+	azureDeployment string
+	azureAPIVersion string
+	// apiStyle selects /responses (default) or /chat/completions.
+	apiStyle string
+	// reasoningEffort is sent to reasoning models, which reject temperature.
+	reasoningEffort string
 }
 
+const (
+	apiStyleResponses       = "responses"
+	apiStyleChatCompletions = "chat_completions"
+)
+
 type openAIResponse struct {
+	Status            string `json:"status"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
 	OutputText string `json:"output_text"`
 	Output     []struct {
 		Type    string `json:"type"`
@@ -34,15 +53,93 @@ type openAIResponse struct {
 	} `json:"error"`
 }
 
+type chatCompletionsResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
 func (c *openAIClient) Provider() string {
+	if c.azureDeployment != "" {
+		return "azure-openai"
+	}
 	return "openai"
 }
 
+func (c *openAIClient) responsesURL() string {
+	if c.azureDeployment == "" {
+		return c.baseURL + "/responses"
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/responses?api-version=%s",
+		c.baseURL, url.PathEscape(c.azureDeployment), url.QueryEscape(c.azureAPIVersion))
+}
+
+func (c *openAIClient) chatCompletionsURL() string {
+	if c.azureDeployment == "" {
+		return c.baseURL + "/chat/completions"
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		c.baseURL, url.PathEscape(c.azureDeployment), url.QueryEscape(c.azureAPIVersion))
+}
+
+// setAuth omits credentials entirely when no key is configured, which local
+// OpenAI-compatible servers (llama.cpp, vLLM) accept.
+func (c *openAIClient) setAuth(req *http.Request) {
+	if c.apiKey == "" {
+		return
+	}
+	if c.azureDeployment != "" {
+		req.Header.Set("api-key", c.apiKey)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+}
+
 func (c *openAIClient) Model() string {
 	return c.model
 }
 
+func (c *openAIClient) Ping(ctx context.Context) error {
+	endpoint := c.baseURL + "/models/" + url.PathEscape(c.model)
+	if c.apiStyle == apiStyleChatCompletions && c.azureDeployment == "" {
+		// Local servers rarely implement /models/{id}; the list is universal.
+		endpoint = c.baseURL + "/models"
+	}
+	if c.azureDeployment != "" {
+		endpoint = fmt.Sprintf("%s/openai/models?api-version=%s", c.baseURL, url.QueryEscape(c.azureAPIVersion))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	c.setAuth(req)
+	httpClient := httpx.NewClient(c.timeout)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s error (%d): %s", c.Provider(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 func (c *openAIClient) Generate(ctx context.Context, prompt Prompt) (string, error) {
+	return c.GenerateWithOptions(ctx, prompt, Options{})
+}
+
+func (c *openAIClient) GenerateWithOptions(ctx context.Context, prompt Prompt, opts Options) (string, error) {
+	if c.apiStyle == apiStyleChatCompletions {
+		return c.generateChat(ctx, prompt, opts)
+	}
 	payload := map[string]any{
 		"model": c.model,
 	}
@@ -70,26 +167,31 @@ func (c *openAIClient) Generate(ctx context.Context, prompt Prompt) (string, err
 		return "", fmt.Errorf("empty prompt")
 	}
 	payload["input"] = input
-	if c.temperature > 0 {
-		payload["temperature"] = c.temperature
+	if c.reasoningEffort != "" {
+		payload["reasoning"] = map[string]any{"effort": c.reasoningEffort}
+	} else if temperature := opts.temperature(c.temperature); temperature > 0 || opts.Temperature != nil {
+		payload["temperature"] = temperature
 	}
-	if c.maxOutputTokens > 0 {
-		payload["max_output_tokens"] = c.maxOutputTokens
+	maxTokens := opts.maxOutputTokens(c.maxOutputTokens)
+	if maxTokens > 0 {
+		payload["max_output_tokens"] = maxTokens
 	}
+	// The Responses API has no stop parameter; stop sequences apply to
+	// chat completions only.
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return "", err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/responses", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.responsesURL(), bytes.NewReader(body))
 	if err != nil {
 		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	c.setAuth(req)
 
-	httpClient := &http.Client{Timeout: c.timeout}
+	httpClient := httpx.NewClient(c.timeout)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", err
@@ -101,7 +203,7 @@ func (c *openAIClient) Generate(ctx context.Context, prompt Prompt) (string, err
 		return "", err
 	}
 	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("openai error (%d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		return "", fmt.Errorf("%s error (%d): %s", c.Provider(), resp.StatusCode, strings.TrimSpace(string(respBody)))
 	}
 
 	var parsed openAIResponse
@@ -109,14 +211,24 @@ func (c *openAIClient) Generate(ctx context.Context, prompt Prompt) (string, err
 		return "", err
 	}
 	if parsed.Error != nil && strings.TrimSpace(parsed.Error.Message) != "" {
-		return "", fmt.Errorf("openai error: %s", parsed.Error.Message)
+		return "", fmt.Errorf("%s error: %s", c.Provider(), parsed.Error.Message)
 	}
 
 	text := strings.TrimSpace(parsed.OutputText)
-	if text != "" {
-		return text, nil
+	if text == "" {
+		text = parsed.messageText()
+	}
+	if parsed.Status == "incomplete" && parsed.IncompleteDetails != nil && parsed.IncompleteDetails.Reason == "max_output_tokens" {
+		return "", &TruncatedError{Provider: c.Provider(), MaxOutputTokens: maxTokens, Partial: text}
 	}
+	if text == "" {
+		return "", fmt.Errorf("openai response had no output_text")
+	}
+	return text, nil
+}
 
+// messageText joins the output_text parts of the response's message items.
+func (parsed openAIResponse) messageText() string {
 	var sb strings.Builder
 	for _, item := range parsed.Output {
 		if item.Type != "message" {
@@ -132,9 +244,80 @@ func (c *openAIClient) Generate(ctx context.Context, prompt Prompt) (string, err
 			sb.WriteString(content.Text)
 		}
 	}
-	text = strings.TrimSpace(sb.String())
+	return strings.TrimSpace(sb.String())
+}
+
+func (c *openAIClient) generateChat(ctx context.Context, prompt Prompt, opts Options) (string, error) {
+	messages := []map[string]string{}
+	if strings.TrimSpace(prompt.System) != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": prompt.System})
+	}
+	if strings.TrimSpace(prompt.User) != "" {
+		messages = append(messages, map[string]string{"role": "user", "content": prompt.User})
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("empty prompt")
+	}
+	payload := map[string]any{
+		"model":    c.model,
+		"messages": messages,
+	}
+	if c.reasoningEffort != "" {
+		payload["reasoning_effort"] = c.reasoningEffort
+	} else if temperature := opts.temperature(c.temperature); temperature > 0 || opts.Temperature != nil {
+		payload["temperature"] = temperature
+	}
+	maxTokens := opts.maxOutputTokens(c.maxOutputTokens)
+	if maxTokens > 0 {
+		payload["max_tokens"] = maxTokens
+	}
+	if len(opts.Stop) > 0 {
+		payload["stop"] = opts.Stop
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.chatCompletionsURL(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuth(req)
+
+	httpClient := httpx.NewClient(c.timeout)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s error (%d): %s", c.Provider(), resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var parsed chatCompletionsResponse
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", err
+	}
+	if parsed.Error != nil && strings.TrimSpace(parsed.Error.Message) != "" {
+		return "", fmt.Errorf("%s error: %s", c.Provider(), parsed.Error.Message)
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("%s response had no choices", c.Provider())
+	}
+	text := strings.TrimSpace(parsed.Choices[0].Message.Content)
+	if parsed.Choices[0].FinishReason == "length" {
+		return "", &TruncatedError{Provider: c.Provider(), MaxOutputTokens: maxTokens, Partial: text}
+	}
 	if text == "" {
-		return "", fmt.Errorf("openai response had no output_text")
+		return "", fmt.Errorf("%s response had empty message content", c.Provider())
 	}
 	return text, nil
 }
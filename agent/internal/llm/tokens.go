@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// messageOverheadTokens approximates the role and framing tokens chat APIs
+// add around each message.
+const messageOverheadTokens = 4
+
+// contextWindows lists input context sizes by model name prefix; the longest
+// matching prefix wins.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"gpt-5":         400000,
+	"o1":            200000,
+	"o3":            200000,
+	"o4":            200000,
+	"llama2":        4096,
+	"llama3":        8192,
+	"llama3.1":      131072,
+	"llama3.2":      131072,
+	"llama3.3":      131072,
+	"mistral":       32768,
+	"mixtral":       32768,
+	"qwen2":         32768,
+	"qwen2.5":       32768,
+	"phi3":          4096,
+	"phi4":          16384,
+	"gemma":         8192,
+	"gemma2":        8192,
+	"tinyllama":     2048,
+}
+
+// ContextWindow returns the context size in tokens of model, or 0 when the
+// model is unknown. Ollama tags (llama3.1:8b) and Azure deployment names
+// that start with the model name are matched by prefix.
+func ContextWindow(model string) int {
+	name := modelName(model)
+	best, window := 0, 0
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(name, prefix) && len(prefix) > best {
+			best, window = len(prefix), size
+		}
+	}
+	return window
+}
+
+// CountTokens estimates how many input tokens prompt takes for model. No
+// exact tokenizer is bundled: text is split the way BPE tokenizers pre-split
+// it (letter runs, digit runs, punctuation) and each run is costed at the
+// model family's typical characters per token. Treat it as a budget figure:
+// it tends to run somewhat high, never to undercount badly. Invalid UTF-8 is
+// rejected.
+func CountTokens(model string, prompt Prompt) (int, error) {
+	if !utf8.ValidString(prompt.System) || !utf8.ValidString(prompt.User) {
+		return 0, errors.New("prompt is not valid UTF-8")
+	}
+	family := tokenFamilyFor(model)
+	total := 0
+	for _, text := range []string{prompt.System, prompt.User} {
+		if text != "" {
+			total += messageOverheadTokens + family.count(text)
+		}
+	}
+	return total, nil
+}
+
+// tokenFamily holds a tokenizer family's average costs.
+type tokenFamily struct {
+	charsPerToken float64 // per letter run
+	digitsPerTok  int     // digits grouped per token
+}
+
+var (
+	// OpenAI's cl100k/o200k vocabularies merge about 4 letters per token and
+	// group digits in threes.
+	openAITokens = tokenFamily{charsPerToken: 4, digitsPerTok: 3}
+	// SentencePiece vocabularies (llama, mistral, gemma) are smaller and
+	// split numbers into single digits.
+	sentencePieceTokens = tokenFamily{charsPerToken: 3.5, digitsPerTok: 1}
+)
+
+func tokenFamilyFor(model string) tokenFamily {
+	name := modelName(model)
+	for _, prefix := range []string{"gpt-", "o1", "o3", "o4", "text-embedding"} {
+		if strings.HasPrefix(name, prefix) {
+			return openAITokens
+		}
+	}
+	return sentencePieceTokens
+}
+
+func (f tokenFamily) count(text string) int {
+	tokens := 0
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch r := runes[i]; {
+		case unicode.IsLetter(r):
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			// Common words are one token; longer ones split roughly evenly.
+			tokens += max(1, int(float64(j-i)/f.charsPerToken+0.5))
+		case unicode.IsDigit(r):
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			tokens += (j - i + f.digitsPerTok - 1) / f.digitsPerTok
+		case unicode.IsSpace(r):
+			// A single space merges into the next word; longer runs and
+			// line breaks cost a token of their own.
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			if j-i > 1 || r == '\n' {
+				tokens++
+			}
+		default:
+			// Adjacent punctuation ("},", "?:") tends to merge in pairs.
+			for j < len(runes) && isPunct(runes[j]) {
+				j++
+			}
+			tokens += (j - i + 1) / 2
+		}
+		i = j
+	}
+	return tokens
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+// modelName lowercases model and drops an Ollama tag or a provider prefix
+// such as "library/".
+func modelName(model string) string {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
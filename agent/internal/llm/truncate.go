@@ -0,0 +1,22 @@
+package llm
+
+import "fmt"
+
+// TruncatedError reports a response cut off at the output-token limit, as
+// opposed to one that is complete but malformed. Callers can retry with a
+// larger MaxOutputTokens or ask for a terser reply.
+type TruncatedError struct {
+	Provider string
+	// MaxOutputTokens is the limit the request was sent with (0 = the
+	// provider's default).
+	MaxOutputTokens int
+	// Partial is whatever text arrived before the cutoff.
+	Partial string
+}
+
+func (e *TruncatedError) Error() string {
+	if e.MaxOutputTokens > 0 {
+		return fmt.Sprintf("%s response truncated at max output tokens (%d)", e.Provider, e.MaxOutputTokens)
+	}
+	return fmt.Sprintf("%s response truncated at max output tokens", e.Provider)
+}
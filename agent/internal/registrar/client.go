@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"agentmarket/agent/internal/httpx"
 )
 
 type Client struct {
@@ -35,9 +37,7 @@ type CreateInvoiceRequest struct {
 func New(baseURL string) *Client {
 	return &Client{
 		BaseURL: strings.TrimRight(baseURL, "/"),
-		HTTP: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		HTTP:    httpx.NewClient(10 * time.Second),
 	}
 }
 
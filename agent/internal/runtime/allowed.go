@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// applyAllowedTokens derives the effective allow list from the policy's
+// tokens, dropping symbols the market does not list and warning once per
+// change. Without a token list nothing is dropped. If every policy token is
+// unknown the policy list is kept as is, so a typo never widens the agent to
+// all tokens.
+func (r *Runner) applyAllowedTokens(tokens []indexer.Token) {
+	if len(tokens) == 0 || len(r.policyAllowedTokens) == 0 {
+		r.allowedTokens = r.policyAllowedTokens
+		r.missingTokens = nil
+		return
+	}
+	listed := map[string]bool{}
+	for _, token := range tokens {
+		listed[strings.ToUpper(strings.TrimSpace(token.Symbol))] = true
+	}
+	effective := make([]string, 0, len(r.policyAllowedTokens))
+	missing := []string{}
+	for _, symbol := range r.policyAllowedTokens {
+		if listed[symbol] {
+			effective = append(effective, symbol)
+		} else {
+			missing = append(missing, symbol)
+		}
+	}
+	if strings.Join(missing, ",") != strings.Join(r.missingTokens, ",") && len(missing) > 0 {
+		if len(effective) == 0 {
+			r.logf(logWarn, "warning: none of the allowed tokens [%s] exist in the market; nothing is tradeable", strings.Join(missing, ", "))
+		} else {
+			r.logf(logWarn, "warning: allowed tokens not in the market, ignoring: [%s]", strings.Join(missing, ", "))
+		}
+	}
+	r.missingTokens = missing
+	if len(effective) == 0 {
+		effective = r.policyAllowedTokens
+	}
+	r.allowedTokens = effective
+}
+
+// syncMarketTokens fetches the token list so explain and status report the
+// effective allow list. Errors leave the policy list unfiltered.
+func (r *Runner) syncMarketTokens(ctx context.Context) {
+	if r.Indexer == nil || len(r.policyAllowedTokens) == 0 {
+		return
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
+	tokens, err := r.Indexer.GetTokens(fetchCtx)
+	cancel()
+	if err != nil {
+		return
+	}
+	r.lastTokens = tokens
+	r.applyAllowedTokens(tokens)
+}
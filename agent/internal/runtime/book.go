@@ -0,0 +1,145 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// fillEstimate is what a marketable order would take from the visible book.
+type fillEstimate struct {
+	Qty      float64
+	AvgPrice float64
+}
+
+type bookLevel struct {
+	price float64
+	qty   float64
+}
+
+// simulateFill walks other agents' open offers (buy) or RFQs (sell) for asset
+// best price first, taking up to qty (0 = no size cap) at prices no worse
+// than limit (<= 0 = no price cap). Buys fill at offer prices and sells at
+// RFQ max prices.
+func (r *Runner) simulateFill(side, asset string, limit, qty float64) fillEstimate {
+	const eps = 1e-9
+	levels := r.bookLevels(side, asset)
+	if side == "buy" {
+		sort.SliceStable(levels, func(i, j int) bool { return levels[i].price < levels[j].price })
+	} else {
+		sort.SliceStable(levels, func(i, j int) bool { return levels[i].price > levels[j].price })
+	}
+	var filled, notional float64
+	for _, level := range levels {
+		if limit > 0 {
+			if side == "buy" && level.price > limit+eps {
+				break
+			}
+			if side == "sell" && level.price+eps < limit {
+				break
+			}
+		}
+		take := level.qty
+		if qty > 0 {
+			take = math.Min(take, qty-filled)
+		}
+		filled += take
+		notional += take * level.price
+		if qty > 0 && filled >= qty-eps {
+			break
+		}
+	}
+	if filled <= eps {
+		return fillEstimate{}
+	}
+	return fillEstimate{Qty: filled, AvgPrice: notional / filled}
+}
+
+func (r *Runner) bookLevels(side, asset string) []bookLevel {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	levels := []bookLevel{}
+	if side == "buy" {
+		for _, offer := range r.lastOffers {
+			if offer.AgentID == r.AgentID || !isOpenStatus(offer.Status) || offer.Qty <= 0 {
+				continue
+			}
+			if strings.ToUpper(strings.TrimSpace(offer.Asset)) == asset {
+				levels = append(levels, bookLevel{price: offer.PriceAGC, qty: offer.Qty})
+			}
+		}
+		return levels
+	}
+	for _, rfq := range r.lastRFQs {
+		if rfq.AgentID == r.AgentID || !isOpenStatus(rfq.Status) || rfq.Qty <= 0 {
+			continue
+		}
+		// An RFQ addressed to another agent is not liquidity this agent can hit.
+		if target := strings.TrimSpace(rfq.TargetAgentID); target != "" && target != r.AgentID {
+			continue
+		}
+		if strings.ToUpper(strings.TrimSpace(rfq.Asset)) == asset {
+			levels = append(levels, bookLevel{price: rfq.MaxPriceAGC, qty: rfq.Qty})
+		}
+	}
+	return levels
+}
+
+// fillSummary describes, per asset with visible liquidity, how much the agent
+// could buy or sell right now and at what average price.
+func (r *Runner) fillSummary() string {
+	assets := map[string]struct{}{}
+	for _, offer := range r.lastOffers {
+		if offer.AgentID != r.AgentID && isOpenStatus(offer.Status) {
+			assets[strings.ToUpper(strings.TrimSpace(offer.Asset))] = struct{}{}
+		}
+	}
+	for _, rfq := range r.lastRFQs {
+		if rfq.AgentID != r.AgentID && isOpenStatus(rfq.Status) {
+			assets[strings.ToUpper(strings.TrimSpace(rfq.Asset))] = struct{}{}
+		}
+	}
+	parts := []string{}
+	for _, asset := range sortedKeys(assets) {
+		if asset == "" {
+			continue
+		}
+		sides := []string{}
+		if buy := r.simulateFill("buy", asset, 0, 0); buy.Qty > 0 {
+			sides = append(sides, fmt.Sprintf("buy up to %.2f at avg %.4f", buy.Qty, buy.AvgPrice))
+		}
+		if sell := r.simulateFill("sell", asset, 0, 0); sell.Qty > 0 {
+			sides = append(sides, fmt.Sprintf("sell up to %.2f at avg %.4f", sell.Qty, sell.AvgPrice))
+		}
+		if len(sides) > 0 {
+			parts = append(parts, asset+": "+strings.Join(sides, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// crossedBooks lists assets where another agent's RFQ bids at or above
+// another agent's offer, i.e. a buy-then-sell captures the gap.
+func (r *Runner) crossedBooks() []string {
+	assets := map[string]struct{}{}
+	for _, offer := range r.lastOffers {
+		assets[strings.ToUpper(strings.TrimSpace(offer.Asset))] = struct{}{}
+	}
+	crosses := []string{}
+	for _, asset := range sortedKeys(assets) {
+		if asset == "" || asset == r.settlement() {
+			continue
+		}
+		bestAsk, bestBid := math.Inf(1), 0.0
+		for _, level := range r.bookLevels("buy", asset) {
+			bestAsk = math.Min(bestAsk, level.price)
+		}
+		for _, level := range r.bookLevels("sell", asset) {
+			bestBid = math.Max(bestBid, level.price)
+		}
+		if bestBid > 0 && bestBid >= bestAsk {
+			crosses = append(crosses, fmt.Sprintf("%s buy at ask %.4f, sell to bid %.4f", asset, bestAsk, bestBid))
+		}
+	}
+	return crosses
+}
@@ -0,0 +1,35 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"agentmarket/agent/internal/llm"
+)
+
+// BuildPrompt returns the prompt the runner would send for its next decision,
+// fetching market data from the indexer when one is configured.
+func (r *Runner) BuildPrompt(ctx context.Context) llm.Prompt {
+	r.refreshBalances(ctx)
+	return r.buildPrompt(ctx)
+}
+
+// CheckResponse parses raw model output and applies the strict schema checks
+// used by decideStrict, without any runner-state repairs. An empty settlement
+// symbol means AGC; strict parses as Runner.StrictParsing does.
+func CheckResponse(raw, settlement string, strict bool) (Action, error) {
+	action, err := parseAction(raw, strict)
+	if err != nil {
+		return Action{}, err
+	}
+	normalizeAction(&action, defaultReasonChars, nil)
+	settlement = strings.ToUpper(strings.TrimSpace(settlement))
+	if settlement == "" {
+		settlement = defaultSettlementSymbol
+	}
+	if msg := validateStrictAction(action, settlement); msg != "" {
+		return action, errors.New(msg)
+	}
+	return action, nil
+}
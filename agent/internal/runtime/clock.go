@@ -0,0 +1,16 @@
+package runtime
+
+import "time"
+
+// Clock is the runner's time source. Tests can supply a fixed or stepped
+// clock; a nil Runner.Clock uses the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+func (r *Runner) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,102 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// CloseAll cancels every open offer and RFQ the agent has and, when sell is
+// set, sells each token holding into the visible RFQ bids. It bypasses the LLM
+// and preflight entirely. Each returned line describes one order closed,
+// sold or skipped; failures are reported in the lines and the joined error.
+func (r *Runner) CloseAll(ctx context.Context, sell bool) ([]string, error) {
+	if r.Indexer == nil {
+		return nil, errors.New("no indexer configured")
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
+	offers, err := r.Indexer.GetOffers(fetchCtx, indexer.ListQuery{Status: "open"})
+	if err == nil {
+		r.lastRFQs, err = r.Indexer.GetRFQs(fetchCtx, indexer.ListQuery{Status: "open"})
+	}
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("fetch open orders: %w", err)
+	}
+	r.lastOffers = offers
+
+	var lines []string
+	var errs []error
+	send := func(req indexer.DevActionRequest, done string) {
+		req.AgentID = r.AgentID
+		req.Reason = "close_all"
+		postCtx, cancel := context.WithTimeout(ctx, r.Timeouts.PostAction)
+		err := r.Indexer.PostDevAction(postCtx, req)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", done, err))
+			lines = append(lines, fmt.Sprintf("FAILED %s: %v", done, err))
+			return
+		}
+		lines = append(lines, done)
+	}
+	for _, offer := range r.lastOffers {
+		if offer.AgentID == r.AgentID && isOpenStatus(offer.Status) {
+			send(indexer.DevActionRequest{Action: "cancel", AssetSymbol: offer.Asset, OfferID: offer.OfferID},
+				fmt.Sprintf("cancelled offer %s (%s q=%.2f p=%.2f)", offer.OfferID, offer.Asset, offer.Qty, offer.PriceAGC))
+		}
+	}
+	for _, rfq := range r.lastRFQs {
+		if rfq.AgentID == r.AgentID && isOpenStatus(rfq.Status) {
+			send(indexer.DevActionRequest{Action: "cancel", AssetSymbol: rfq.Asset, RFQID: rfq.RFQID},
+				fmt.Sprintf("cancelled rfq %s (%s q=%.2f max=%.2f)", rfq.RFQID, rfq.Asset, rfq.Qty, rfq.MaxPriceAGC))
+		}
+	}
+	if !sell {
+		return lines, errors.Join(errs...)
+	}
+
+	r.invalidateBalances()
+	r.refreshBalances(ctx)
+	if r.lastBalances == nil {
+		errs = append(errs, errors.New("balances unavailable; nothing sold"))
+		return lines, errors.Join(errs...)
+	}
+	for _, asset := range sortedKeys(r.lastBalances) {
+		held := r.lastBalances[asset]
+		if asset == r.settlement() || held == 0 {
+			continue
+		}
+		qty, limit := r.sellDepth(asset, float64(held))
+		if qty < 1 {
+			lines = append(lines, fmt.Sprintf("no bids for %s; %d left unsold", asset, held))
+			continue
+		}
+		done := fmt.Sprintf("sold %.0f %s down to %.4f", qty, asset, limit)
+		if uint64(qty) < held {
+			done += fmt.Sprintf(" (%d left; not enough bids)", held-uint64(qty))
+		}
+		send(indexer.DevActionRequest{Action: "trade", AssetSymbol: asset, Side: "sell", Qty: qty, PriceAGC: limit}, done)
+	}
+	return lines, errors.Join(errs...)
+}
+
+// sellDepth is how many whole units of asset (up to qty) the other agents'
+// RFQs absorb, best bid first, and the lowest price reached doing so.
+func (r *Runner) sellDepth(asset string, qty float64) (float64, float64) {
+	levels := r.bookLevels("sell", asset)
+	sort.SliceStable(levels, func(i, j int) bool { return levels[i].price > levels[j].price })
+	filled, limit := 0.0, 0.0
+	for _, level := range levels {
+		if filled >= qty {
+			break
+		}
+		filled += math.Min(level.qty, qty-filled)
+		limit = level.price
+	}
+	return math.Floor(filled), limit
+}
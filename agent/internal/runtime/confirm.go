@@ -0,0 +1,74 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// fillPollInterval spaces GetAgentTrades calls while awaiting a fill.
+const fillPollInterval = 500 * time.Millisecond
+
+// fillBaseline records the trade IDs already on file before a trade is
+// posted, so awaitFill can tell its fills apart. ok is false when fill
+// confirmation is off, the action is not a trade, or the fetch failed.
+func (r *Runner) fillBaseline(ctx context.Context, action string) (map[string]bool, bool) {
+	if r.ConfirmFills <= 0 || action != "trade" || r.Indexer == nil {
+		return nil, false
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
+	trades, err := r.Indexer.GetAgentTrades(fetchCtx, r.AgentID)
+	cancel()
+	if err != nil {
+		r.logf(logWarn, "fill confirmation skipped: %v", err)
+		return nil, false
+	}
+	seen := make(map[string]bool, len(trades))
+	for _, trade := range trades {
+		seen[trade.TradeID] = true
+	}
+	return seen, true
+}
+
+// awaitFill polls the agent's trades for new fills of asset on side until
+// qty is filled or ConfirmFills elapses, and returns what filled. It reports
+// false when nothing filled in time.
+func (r *Runner) awaitFill(ctx context.Context, baseline map[string]bool, asset, side string, qty float64) (fillEstimate, bool) {
+	const eps = 1e-9
+	deadline := r.now().Add(r.ConfirmFills)
+	var filled, notional float64
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
+		trades, err := r.Indexer.GetAgentTrades(fetchCtx, r.AgentID)
+		cancel()
+		if err == nil {
+			for _, trade := range trades {
+				if baseline[trade.TradeID] || trade.Qty <= 0 {
+					continue
+				}
+				if !strings.EqualFold(strings.TrimSpace(trade.AssetSymbol), asset) || !strings.EqualFold(strings.TrimSpace(trade.Side), side) {
+					continue
+				}
+				baseline[trade.TradeID] = true
+				filled += trade.Qty
+				notional += trade.Qty * trade.PriceAGC
+			}
+		}
+		if filled >= qty-eps || !r.now().Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fillResult(filled, notional)
+		case <-time.After(fillPollInterval):
+		}
+	}
+	return fillResult(filled, notional)
+}
+
+func fillResult(filled, notional float64) (fillEstimate, bool) {
+	if filled <= 0 {
+		return fillEstimate{}, false
+	}
+	return fillEstimate{Qty: filled, AvgPrice: notional / filled}, true
+}
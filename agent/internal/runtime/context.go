@@ -0,0 +1,65 @@
+package runtime
+
+import "agentmarket/agent/internal/llm"
+
+// promptBudgetFraction is the share of the context window the prompt may
+// fill, leaving the rest for the reply.
+const promptBudgetFraction = 0.9
+
+// promptSections sizes the trimmable parts of the user prompt: how many
+// decision memory entries and orderbook lens rows it includes.
+type promptSections struct {
+	memory   int
+	bookRows int
+}
+
+// contextWindow is ContextTokens, or the model's known context window.
+func (r *Runner) contextWindow() int {
+	if r.ContextTokens > 0 {
+		return r.ContextTokens
+	}
+	if r.LLM == nil {
+		return 0
+	}
+	return llm.ContextWindow(r.LLM.Model())
+}
+
+// fitPrompt renders the user prompt in full. When the context window is
+// known and the prompt would fill more than promptBudgetFraction of it, the
+// lowest-priority sections are given up until it fits: the oldest decision
+// memory first, then the lowest-ranked orderbook lens rows down to the top
+// one. A warning is logged whenever anything was trimmed.
+func (r *Runner) fitPrompt(prompt llm.Prompt, render func(promptSections) string) llm.Prompt {
+	keep := promptSections{memory: promptMemoryEntries, bookRows: promptBookRows}
+	prompt.User = render(keep)
+	window := r.contextWindow()
+	if window == 0 {
+		return prompt
+	}
+	model := ""
+	if r.LLM != nil {
+		model = r.LLM.Model()
+	}
+	budget := int(float64(window) * promptBudgetFraction)
+	tokens, err := llm.CountTokens(model, prompt)
+	if err != nil || tokens <= budget {
+		return prompt
+	}
+	before := tokens
+	for tokens > budget && (keep.memory > 0 || keep.bookRows > 1) {
+		if keep.memory > 0 {
+			keep.memory--
+		} else {
+			keep.bookRows--
+		}
+		prompt.User = render(keep)
+		tokens, _ = llm.CountTokens(model, prompt)
+	}
+	over := ""
+	if tokens > budget {
+		over = ", still over budget"
+	}
+	r.logf(logWarn, "warning: prompt ~%d tokens is near the %d-token context window; trimmed to %d memory entries and %d orderbook rows (~%d tokens%s)",
+		before, window, keep.memory, keep.bookRows, tokens, over)
+	return prompt
+}
@@ -0,0 +1,172 @@
+package runtime
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is a point-in-time view of the runner, safe to hand to other
+// goroutines. The run loop refreshes it every tick.
+type Status struct {
+	AgentID           string            `json:"agent_id"`
+	Profile           string            `json:"profile"`
+	State             string            `json:"state"`
+	Paused            bool              `json:"paused"`
+	NextDecisionAt    string            `json:"next_decision_at,omitempty"`
+	Cycles            uint64            `json:"cycles"`
+	Decisions         int               `json:"decisions"`
+	StrategyPrompt    string            `json:"strategy_prompt"`
+	StrategyVersion   string            `json:"strategy_version,omitempty"`
+	StrategySupported bool              `json:"strategy_supported"`
+	AllowedTokens     []string          `json:"allowed_tokens,omitempty"`
+	MissingTokens     []string          `json:"missing_tokens,omitempty"`
+	Watch             *Watch            `json:"watch,omitempty"`
+	Balances          map[string]uint64 `json:"balances,omitempty"`
+	UpdatedAt         string            `json:"updated_at"`
+}
+
+// control holds the state shared between the run loop and the exported
+// control methods, all guarded by mu. status and metrics are copies the loop
+// publishes; readers never touch the loop-owned fields directly.
+type control struct {
+	mu               sync.Mutex
+	paused           bool
+	strategyOverride string
+	decideNow        chan struct{}
+	status           Status
+	metrics          Metrics
+	outboxQueued     int
+}
+
+// Pause stops decisions (heartbeats continue) until Resume is called.
+func (r *Runner) Pause() {
+	r.ctrl.mu.Lock()
+	r.ctrl.paused = true
+	r.ctrl.mu.Unlock()
+}
+
+// Resume undoes Pause. A pause file, if configured and present, still applies.
+func (r *Runner) Resume() {
+	r.ctrl.mu.Lock()
+	r.ctrl.paused = false
+	r.ctrl.mu.Unlock()
+}
+
+// DecideNow asks the run loop to make a decision immediately instead of
+// waiting for the next scheduled one. Repeated calls before the loop reacts
+// collapse into one.
+func (r *Runner) DecideNow() {
+	select {
+	case r.decideNowChan() <- struct{}{}:
+	default:
+	}
+}
+
+// SetStrategyPrompt overrides the on-chain strategy prompt until cleared
+// with an empty string.
+func (r *Runner) SetStrategyPrompt(prompt string) {
+	r.ctrl.mu.Lock()
+	r.ctrl.strategyOverride = strings.TrimSpace(prompt)
+	r.ctrl.mu.Unlock()
+}
+
+// Status returns a copy of the snapshot last published by the run loop.
+func (r *Runner) Status() Status {
+	r.ctrl.mu.Lock()
+	status := r.ctrl.status
+	r.ctrl.mu.Unlock()
+	status.AllowedTokens = append([]string(nil), status.AllowedTokens...)
+	status.MissingTokens = append([]string(nil), status.MissingTokens...)
+	if status.Watch != nil {
+		watch := *status.Watch
+		status.Watch = &watch
+	}
+	status.Balances = copyCounts(status.Balances)
+	return status
+}
+
+// metricsSnapshot returns a copy of the counters last published by the run
+// loop, plus the outbox depth at that time.
+func (r *Runner) metricsSnapshot() (Metrics, int) {
+	r.ctrl.mu.Lock()
+	defer r.ctrl.mu.Unlock()
+	metrics := r.ctrl.metrics
+	metrics.DecisionsStatus = copyCounts(metrics.DecisionsStatus)
+	return metrics, r.ctrl.outboxQueued
+}
+
+func copyCounts(in map[string]uint64) map[string]uint64 {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]uint64, len(in))
+	for key, value := range in {
+		out[key] = value
+	}
+	return out
+}
+
+func (r *Runner) controlPaused() bool {
+	r.ctrl.mu.Lock()
+	defer r.ctrl.mu.Unlock()
+	return r.ctrl.paused
+}
+
+// strategyPrompt is the prompt in effect: an operator override wins over a
+// local StrategyPromptFile, which wins over the registered one.
+func (r *Runner) strategyPrompt() string {
+	r.ctrl.mu.Lock()
+	override := r.ctrl.strategyOverride
+	r.ctrl.mu.Unlock()
+	if override != "" {
+		return override
+	}
+	if local := r.localStrategyPrompt(); local != "" {
+		return local
+	}
+	return strings.TrimSpace(r.StrategyPrompt)
+}
+
+func (r *Runner) decideNowChan() chan struct{} {
+	r.ctrl.mu.Lock()
+	defer r.ctrl.mu.Unlock()
+	if r.ctrl.decideNow == nil {
+		r.ctrl.decideNow = make(chan struct{}, 1)
+	}
+	return r.ctrl.decideNow
+}
+
+// publishStatus copies the loop-owned fields into the shared snapshots.
+func (r *Runner) publishStatus() {
+	status := Status{
+		AgentID:           r.AgentID,
+		Profile:           r.Profile,
+		State:             r.state,
+		Paused:            r.paused,
+		Cycles:            r.cycle,
+		Decisions:         r.decisionCount,
+		StrategyPrompt:    r.strategyPrompt(),
+		StrategyVersion:   r.strategyVersion,
+		StrategySupported: StrategyVersionSupported(r.strategyVersion),
+		AllowedTokens:     append([]string(nil), r.allowedTokens...),
+		MissingTokens:     append([]string(nil), r.missingTokens...),
+		UpdatedAt:         r.now().UTC().Format(time.RFC3339),
+	}
+	if !r.nextDecisionAt.IsZero() && !r.paused {
+		status.NextDecisionAt = r.nextDecisionAt.UTC().Format(time.RFC3339)
+	}
+	if r.watch != nil {
+		watch := *r.watch
+		status.Watch = &watch
+	}
+	status.Balances = copyCounts(r.lastBalances)
+	metrics := r.metrics
+	metrics.Cycles = r.cycle
+	metrics.DecisionsStatus = copyCounts(r.metrics.DecisionsStatus)
+	r.ctrl.mu.Lock()
+	r.ctrl.status = status
+	r.ctrl.metrics = metrics
+	r.ctrl.outboxQueued = len(r.outbox)
+	r.ctrl.mu.Unlock()
+}
@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestControlConcurrentWithRun drives the control API from several
+// goroutines while Run is deciding; run it with -race.
+func TestControlConcurrentWithRun(t *testing.T) {
+	r, _ := newTestRunner(t, `{"action":"wait","next_check_sec":1,"reason":"test"}`)
+	r.Tick = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.Run(ctx) }()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; ctx.Err() == nil; i++ {
+				switch i % 6 {
+				case 0:
+					r.Pause()
+				case 1:
+					r.Resume()
+				case 2:
+					r.SetStrategyPrompt(fmt.Sprintf("strategy %d-%d", worker, i))
+				case 3:
+					r.DecideNow()
+				case 4:
+					_ = r.Status()
+				case 5:
+					_ = r.WriteMetrics(io.Discard)
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	if err := <-runErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("run returned %v, want deadline exceeded", err)
+	}
+	status := r.Status()
+	if status.Cycles == 0 {
+		t.Fatal("run loop made no cycles while the control API was in use")
+	}
+	r.Resume()
+	r.SetStrategyPrompt("final")
+	if got := r.strategyPrompt(); got != "final" {
+		t.Fatalf("strategy prompt %q, want %q", got, "final")
+	}
+}
@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"agentmarket/agent/internal/httpx"
+)
+
+const (
+	defaultOperatorPing    = 30 * time.Second
+	defaultOperatorTimeout = 5 * time.Minute
+)
+
+// checkOperator is the dead-man's switch. It pings OperatorURL every
+// OperatorPing and, once the operator has been unreachable for longer than
+// OperatorTimeout, pauses the runner (closing all orders first when
+// OperatorCloseAll is set). Contact again lifts the pause.
+func (r *Runner) checkOperator(ctx context.Context) {
+	if r.OperatorURL == "" {
+		return
+	}
+	now := r.now()
+	if r.operatorContactAt.IsZero() {
+		r.operatorContactAt = now
+	}
+	ping := r.OperatorPing
+	if ping <= 0 {
+		ping = defaultOperatorPing
+	}
+	if now.Sub(r.operatorPingAt) < ping {
+		return
+	}
+	r.operatorPingAt = now
+	err := r.pingOperator(ctx)
+	if err == nil {
+		r.operatorContactAt = now
+		if r.operatorLost {
+			r.logf(logInfo, "operator reachable again; lifting dead-man's switch")
+			r.operatorLost = false
+		}
+		return
+	}
+	if !r.operatorLost {
+		r.logf(logWarn, "operator ping failed: %v", err)
+	}
+	window := r.OperatorTimeout
+	if window <= 0 {
+		window = defaultOperatorTimeout
+	}
+	if r.operatorLost || now.Sub(r.operatorContactAt) < window {
+		return
+	}
+	r.operatorLost = true
+	r.logf(logWarn, "operator unreachable for %s; dead-man's switch tripped", now.Sub(r.operatorContactAt).Round(time.Second))
+	if r.OperatorCloseAll {
+		lines, err := r.CloseAll(ctx, false)
+		for _, line := range lines {
+			r.logf(logInfo, "dead-man close-out: %s", line)
+		}
+		if err != nil {
+			r.logf(logError, "dead-man close-out incomplete: %v", err)
+		}
+	}
+}
+
+func (r *Runner) pingOperator(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(pingCtx, http.MethodGet, r.OperatorURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpx.NewClient(5 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
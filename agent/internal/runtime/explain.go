@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SyncAgentConfig pulls the on-chain strategy prompt and policy from the
+// indexer, as the run loop does before each prompt.
+func (r *Runner) SyncAgentConfig(ctx context.Context) {
+	r.refreshAgentConfig(ctx)
+	r.syncMarketTokens(ctx)
+}
+
+// Explain summarizes, in plain language, how the runner is configured to
+// behave. It only reads existing settings.
+func (r *Runner) Explain() string {
+	lines := []string{
+		fmt.Sprintf("Profile: %s. %s", r.Profile, profilePrompt(r.Profile)),
+	}
+	tokens := "any listed token except " + r.settlement()
+	if len(r.allowedTokens) > 0 {
+		tokens = strings.Join(r.allowedTokens, ", ")
+	}
+	if len(r.missingTokens) > 0 {
+		tokens += fmt.Sprintf(" (ignoring %s: not listed in the market)", strings.Join(r.missingTokens, ", "))
+	}
+	lines = append(lines, "Trades: "+tokens+".")
+	lines = append(lines, fmt.Sprintf("Actions: %s (plus wait).", strings.Join(r.permittedActions(), ", ")))
+	lines = append(lines, fmt.Sprintf("Limits: at most %d open offers (%d per asset) and %d open RFQs.",
+		maxOpenOffersPerAgent, maxOpenOffersPerAsset, maxOpenRFQsPerAgent))
+	lines = append(lines, fmt.Sprintf("Fees: trades cost %d bps.", tradeFeeBps))
+	if r.MinAGCReserve > 0 {
+		lines = append(lines, fmt.Sprintf("Reserve: never spends below %d %s.", r.MinAGCReserve, r.settlement()))
+	}
+	if r.PriceBandBps > 0 {
+		lines = append(lines, fmt.Sprintf("Price band: orders within %.2f%% of the current price.", r.PriceBandBps/100))
+	}
+	if r.hasProfile("market_maker") && (r.SpreadBps > 0 || len(r.AssetSpreadBps) > 0) {
+		spread := "model's choice"
+		if r.SpreadBps > 0 {
+			spread = fmt.Sprintf("%.0f bps", r.SpreadBps)
+		}
+		lines = append(lines, fmt.Sprintf("Spread: quotes %s around the current price%s.", spread, assetSpreadSuffix(r.AssetSpreadBps)))
+	}
+	if r.reduceOnly() {
+		lines = append(lines, "Reduce-only: only sells down existing holdings.")
+	}
+	if r.MinConfidence > 0 {
+		lines = append(lines, fmt.Sprintf("Acts only when the model's confidence is at least %.2f.", r.MinConfidence))
+	}
+	if r.AssetCooldown > 0 {
+		lines = append(lines, fmt.Sprintf("Cooldown: pauses an asset for %s after a rejection.", r.AssetCooldown))
+	}
+	if r.MaxOrderEquityFraction > 0 {
+		lines = append(lines, fmt.Sprintf("Sizing: each order is capped at %.0f%% of equity.", r.MaxOrderEquityFraction*100))
+	}
+	if r.StalePriceAfter > 0 {
+		line := fmt.Sprintf("Stale prices: marks with no trade for %s are flagged", r.StalePriceAfter)
+		if r.BlockStalePrices {
+			line += " and orders on them blocked"
+		}
+		lines = append(lines, line+".")
+	}
+	if ticks := r.tickNote(); ticks != "" {
+		lines = append(lines, ticks+".")
+	}
+	if r.MinNotionalAGC > 0 {
+		lines = append(lines, fmt.Sprintf("Minimum notional: orders under %.2f %s are blocked.", r.MinNotionalAGC, r.settlement()))
+	}
+	switch r.rfqFillMode() {
+	case rfqFillClamp:
+		lines = append(lines, "RFQ sizing: RFQs are cut down to what visible offers could fill.")
+	case rfqFillBlock:
+		lines = append(lines, "RFQ sizing: RFQs larger than visible offers could fill are blocked.")
+	}
+	if tokens := r.mintableTokens(); len(tokens) > 0 && r.actionPermitted("mint") {
+		lines = append(lines, "Minting: may mint "+strings.Join(tokens, ", ")+" explicitly with the mint action.")
+	}
+	if r.actionPermitted("respond_rfq") {
+		lines = append(lines, "Negotiation: answers RFQs other agents address to it with sell quotes.")
+	}
+	if r.MaxActionsPerMinute > 0 {
+		lines = append(lines, fmt.Sprintf("Rate limit: at most %d actions per minute.", r.MaxActionsPerMinute))
+	}
+	if r.MinWait > 0 || r.MaxWait > 0 {
+		lo, hi := r.waitBounds()
+		lines = append(lines, fmt.Sprintf("Cadence: waits between decisions are kept within %s-%s.", lo, hi))
+	}
+	if r.MaxConsecutiveWaits > 0 {
+		line := fmt.Sprintf("After %d waits in a row the model is told to act", r.MaxConsecutiveWaits)
+		if r.WaitFallback {
+			line += "; if it still waits, a one-unit heuristic trade is tried"
+		}
+		lines = append(lines, line+".")
+	}
+	if r.MaxDecisions > 0 {
+		lines = append(lines, fmt.Sprintf("Stops deciding after %d decisions.", r.MaxDecisions))
+	}
+	strategy := "none (profile defaults only)"
+	if prompt := r.strategyPrompt(); prompt != "" {
+		strategy = firstSentence(prompt, 160)
+	}
+	lines = append(lines, "Strategy: "+strategy)
+	return strings.Join(lines, "\n")
+}
+
+// firstSentence returns the prompt's opening sentence, capped at max bytes.
+func firstSentence(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if i := strings.IndexAny(text, ".!?"); i >= 0 && i+1 < len(text) {
+		text = text[:i+1]
+	}
+	return trimForPrompt(text, max)
+}
+
+// assetSpreadSuffix describes per-asset spread overrides, e.g. " (BTC 40 bps)".
+func assetSpreadSuffix(spreads map[string]float64) string {
+	if len(spreads) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(spreads))
+	for _, asset := range sortedKeys(spreads) {
+		parts = append(parts, fmt.Sprintf("%s %.0f bps", asset, spreads[asset]))
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,37 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+)
+
+// marketHalted fetches the indexer's market status and reports whether
+// trading is halted upstream, logging each transition. Fetch errors assume
+// the market is open, leaving the other fetches to surface the outage.
+func (r *Runner) marketHalted(ctx context.Context) bool {
+	if r.Indexer == nil {
+		return false
+	}
+	fetchCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
+	status, err := r.Indexer.GetMarketStatus(fetchCtx)
+	cancel()
+	if err != nil {
+		r.logf(logDebug, "market status unavailable, assuming open: %v", err)
+		status.Status = "open"
+	}
+	halted := status.Halted()
+	if halted && !r.halted {
+		line := "market " + strings.ToLower(strings.TrimSpace(status.Status))
+		if status.Reason != "" {
+			line += ": " + status.Reason
+		}
+		if status.ResumesAt != "" {
+			line += " (resumes " + status.ResumesAt + ")"
+		}
+		r.logf(logInfo, "%s; skipping decisions", line)
+	} else if !halted && r.halted {
+		r.logf(logInfo, "market open again; resuming decisions")
+	}
+	r.halted = halted
+	return halted
+}
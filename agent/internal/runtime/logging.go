@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// logLevel orders runtime log lines; a line prints when its level is at or
+// below the runner's.
+type logLevel int
+
+const (
+	logError logLevel = iota // failed actions, posts and saves
+	logWarn                  // degraded operation the runner works around
+	logInfo                  // executed actions and state changes
+	logDebug                 // raw model output per attempt
+)
+
+var logLevels = map[string]logLevel{
+	"error": logError,
+	"warn":  logWarn,
+	"info":  logInfo,
+	"debug": logDebug,
+}
+
+// logLevel resolves the runner's level: Verbose (the --verbose flag) means
+// debug whatever the config says, then LogLevel, then info.
+func (r *Runner) logLevel() logLevel {
+	if r.Verbose {
+		return logDebug
+	}
+	if level, ok := logLevels[strings.ToLower(strings.TrimSpace(r.LogLevel))]; ok {
+		return level
+	}
+	return logInfo
+}
+
+// logf prints a line to stdout when level is enabled.
+func (r *Runner) logf(level logLevel, format string, args ...any) {
+	if level > r.logLevel() {
+		return
+	}
+	fmt.Printf(format+"\n", args...)
+}
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"agentmarket/agent/internal/httpx"
+)
+
+// Metrics holds the runner counters exposed in Prometheus text format.
+type Metrics struct {
+	Cycles          uint64
+	LLMRequests     uint64
+	LLMErrors       uint64
+	DecisionsStatus map[string]uint64
+	OutboxDropped   uint64
+}
+
+func (r *Runner) countDecision(status string) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		status = "logged"
+	}
+	if r.metrics.DecisionsStatus == nil {
+		r.metrics.DecisionsStatus = map[string]uint64{}
+	}
+	r.metrics.DecisionsStatus[status]++
+}
+
+// WriteMetrics renders the runner counters in the Prometheus text exposition
+// format. It reads the run loop's last published snapshot, so it is safe to
+// call from any goroutine.
+func (r *Runner) WriteMetrics(w io.Writer) error {
+	metrics, outboxQueued := r.metricsSnapshot()
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# TYPE agentd_cycles_total counter")
+	fmt.Fprintf(&buf, "agentd_cycles_total %d\n", metrics.Cycles)
+	fmt.Fprintln(&buf, "# TYPE agentd_llm_requests_total counter")
+	fmt.Fprintf(&buf, "agentd_llm_requests_total %d\n", metrics.LLMRequests)
+	fmt.Fprintln(&buf, "# TYPE agentd_llm_errors_total counter")
+	fmt.Fprintf(&buf, "agentd_llm_errors_total %d\n", metrics.LLMErrors)
+	fmt.Fprintln(&buf, "# TYPE agentd_outbox_queued gauge")
+	fmt.Fprintf(&buf, "agentd_outbox_queued %d\n", outboxQueued)
+	fmt.Fprintln(&buf, "# TYPE agentd_outbox_dropped_total counter")
+	fmt.Fprintf(&buf, "agentd_outbox_dropped_total %d\n", metrics.OutboxDropped)
+	fmt.Fprintln(&buf, "# TYPE agentd_decisions_total counter")
+	statuses := make([]string, 0, len(metrics.DecisionsStatus))
+	for status := range metrics.DecisionsStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(&buf, "agentd_decisions_total{status=%q} %d\n", status, metrics.DecisionsStatus[status])
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// PushMetrics sends the current counters to a Prometheus Pushgateway, grouped
+// under job "agentd" and the agent ID as instance.
+func (r *Runner) PushMetrics(ctx context.Context, gatewayURL string) error {
+	gatewayURL = strings.TrimRight(strings.TrimSpace(gatewayURL), "/")
+	if gatewayURL == "" {
+		return nil
+	}
+	instance := strings.TrimSpace(r.AgentID)
+	if instance == "" {
+		instance = "anonymous"
+	}
+	var body bytes.Buffer
+	if err := r.WriteMetrics(&body); err != nil {
+		return err
+	}
+	endpoint := gatewayURL + "/metrics/job/agentd/instance/" + url.PathEscape(instance)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	client := httpx.NewClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg := "pushgateway request failed"
+		if b, err := io.ReadAll(io.LimitReader(resp.Body, 4096)); err == nil {
+			trimmed := strings.TrimSpace(string(b))
+			if trimmed != "" {
+				msg = fmt.Sprintf("%s: %s", msg, trimmed)
+			}
+		}
+		return fmt.Errorf("%s (status %d)", msg, resp.StatusCode)
+	}
+	return nil
+}
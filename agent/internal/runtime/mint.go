@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mintableTokens lists the synthetic assets the agent's policy lets it mint,
+// excluding the settlement symbol and anything outside the allowed tokens.
+func (r *Runner) mintableTokens() []string {
+	allowed := map[string]struct{}{}
+	for _, symbol := range r.allowedTokens {
+		allowed[symbol] = struct{}{}
+	}
+	out := []string{}
+	for _, token := range r.policyMintTokens {
+		symbol := strings.ToUpper(strings.TrimSpace(token))
+		if symbol == "" || symbol == r.settlement() {
+			continue
+		}
+		if _, ok := allowed[symbol]; len(allowed) > 0 && !ok {
+			continue
+		}
+		out = append(out, symbol)
+	}
+	return out
+}
+
+func (r *Runner) canMint(asset string) bool {
+	for _, symbol := range r.mintableTokens() {
+		if symbol == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// mintNote tells the model which assets it can mint and what that costs
+// ("" when minting is not permitted or nothing is mintable).
+func (r *Runner) mintNote() string {
+	if !r.actionPermitted("mint") || r.reduceOnly() {
+		return ""
+	}
+	tokens := r.mintableTokens()
+	if len(tokens) == 0 {
+		return ""
+	}
+	note := fmt.Sprintf("You can mint these synthetic assets with action 'mint' (asset_symbol, qty) at %d %s per unit: %s",
+		syntheticMintFeePerUnitAGC, r.settlement(), strings.Join(tokens, ", "))
+	if r.MaxMintQty > 0 {
+		note += fmt.Sprintf("; at most %d per mint", r.MaxMintQty)
+	}
+	return note
+}
+
+// repairMint fills a mint's missing asset with the first mintable token and
+// a missing qty with MaxMintQty (or 1).
+func (r *Runner) repairMint(action *Action) {
+	if strings.TrimSpace(action.AssetSymbol) == "" {
+		if tokens := r.mintableTokens(); len(tokens) > 0 {
+			action.AssetSymbol = tokens[0]
+		}
+	}
+	if action.Qty <= 0 {
+		action.Qty = 1
+		if r.MaxMintQty > 0 {
+			action.Qty = float64(r.MaxMintQty)
+		}
+	}
+	action.Side = ""
+	action.PriceAGC = 0
+}
+
+// preflightMint checks a mint against the policy's mint allowlist,
+// MaxMintQty and the settlement balance needed for the mint fee.
+func (r *Runner) preflightMint(asset string, qty uint64) (string, string) {
+	if !r.canMint(asset) {
+		return "blocked", "asset not mintable by policy"
+	}
+	if r.MaxMintQty > 0 && qty > r.MaxMintQty {
+		return "blocked", fmt.Sprintf("mint qty above max %d", r.MaxMintQty)
+	}
+	if r.spendableAGC() < qty*syntheticMintFeePerUnitAGC {
+		return "blocked", "insufficient " + r.settlement() + " for mint fee"
+	}
+	return "", ""
+}
@@ -0,0 +1,32 @@
+package runtime
+
+import "strings"
+
+// insufficientData returns the wait reason when the last fetched market
+// data leaves nothing to decide on, so the LLM call can be skipped: no
+// listed token the agent may trade, or balances that are entirely empty.
+// Data that failed to load is not judged here.
+func (r *Runner) insufficientData() string {
+	if r.tokensLoaded && !r.hasTradeableToken() {
+		return "no_tradeable_tokens"
+	}
+	if r.lastBalances != nil {
+		for _, amount := range r.lastBalances {
+			if amount > 0 {
+				return ""
+			}
+		}
+		return "no_balances"
+	}
+	return ""
+}
+
+func (r *Runner) hasTradeableToken() bool {
+	for _, token := range r.lastTokens {
+		symbol := strings.ToUpper(strings.TrimSpace(token.Symbol))
+		if symbol != "" && r.tokenAllowed(symbol) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+const (
+	defaultOutboxSize = 256
+	maxOutboxBackoff  = time.Minute
+)
+
+// outboxItem is a decision or heartbeat post that failed and awaits a retry.
+type outboxItem struct {
+	Decision  *indexer.DevDecisionRequest  `json:"decision,omitempty"`
+	Heartbeat *indexer.DevHeartbeatRequest `json:"heartbeat,omitempty"`
+	RequestID string                       `json:"request_id,omitempty"`
+	QueuedAt  time.Time                    `json:"queued_at"`
+}
+
+func (r *Runner) outboxSize() int {
+	if r.OutboxSize > 0 {
+		return r.OutboxSize
+	}
+	return defaultOutboxSize
+}
+
+// enqueue adds a failed post to the outbox, dropping the oldest entry when
+// the outbox is full. Only the newest heartbeat is kept; older ones would
+// report a stale state.
+func (r *Runner) enqueue(item outboxItem) {
+	if item.Heartbeat != nil {
+		r.dropQueuedHeartbeats()
+	}
+	item.QueuedAt = r.now().UTC()
+	if len(r.outbox) >= r.outboxSize() {
+		r.outbox = r.outbox[1:]
+		r.metrics.OutboxDropped++
+		r.logf(logWarn, "outbox full (%d); dropped the oldest queued post", r.outboxSize())
+	}
+	r.outbox = append(r.outbox, item)
+	r.saveOutbox()
+}
+
+func (r *Runner) dropQueuedHeartbeats() {
+	kept := r.outbox[:0]
+	for _, item := range r.outbox {
+		if item.Heartbeat == nil {
+			kept = append(kept, item)
+		}
+	}
+	r.outbox = kept
+}
+
+// drainOutbox retries queued posts in order, stopping at the first failure
+// and backing off exponentially (capped at a minute) before trying again.
+func (r *Runner) drainOutbox(ctx context.Context) {
+	if len(r.outbox) == 0 || r.Indexer == nil || r.now().Before(r.outboxRetryAt) {
+		return
+	}
+	sent := 0
+	for len(r.outbox) > 0 {
+		if err := r.sendOutboxItem(ctx, r.outbox[0]); err != nil {
+			r.outboxFailures++
+			backoff := time.Second << min(r.outboxFailures, 6)
+			if backoff > maxOutboxBackoff {
+				backoff = maxOutboxBackoff
+			}
+			r.outboxRetryAt = r.now().Add(backoff)
+			break
+		}
+		r.outbox = r.outbox[1:]
+		sent++
+	}
+	if sent == 0 {
+		return
+	}
+	if len(r.outbox) == 0 {
+		r.outboxFailures = 0
+		r.logf(logInfo, "outbox flushed: %d queued posts delivered", sent)
+	}
+	r.saveOutbox()
+}
+
+func (r *Runner) sendOutboxItem(ctx context.Context, item outboxItem) error {
+	if item.RequestID != "" {
+		ctx = indexer.WithRequestID(ctx, item.RequestID)
+	}
+	switch {
+	case item.Decision != nil:
+		postCtx, cancel := context.WithTimeout(ctx, r.Timeouts.PostDecision)
+		defer cancel()
+		return r.Indexer.PostDevDecision(postCtx, *item.Decision)
+	case item.Heartbeat != nil:
+		postCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Heartbeat)
+		defer cancel()
+		return r.Indexer.PostDevHeartbeat(postCtx, *item.Heartbeat)
+	}
+	return nil
+}
+
+// loadOutbox restores posts queued by a previous run from OutboxFile.
+func (r *Runner) loadOutbox() {
+	if r.OutboxFile == "" {
+		return
+	}
+	b, err := os.ReadFile(r.OutboxFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	var items []outboxItem
+	if err == nil {
+		err = json.Unmarshal(b, &items)
+	}
+	if err != nil {
+		r.logf(logWarn, "outbox %s unreadable, starting empty: %v", r.OutboxFile, err)
+		return
+	}
+	if over := len(items) - r.outboxSize(); over > 0 {
+		items = items[over:]
+		r.metrics.OutboxDropped += uint64(over)
+	}
+	r.outbox = items
+	if len(items) > 0 {
+		r.logf(logInfo, "outbox: %d queued posts restored from %s", len(items), r.OutboxFile)
+	}
+}
+
+// saveOutbox mirrors the outbox to OutboxFile, replacing it atomically.
+func (r *Runner) saveOutbox() {
+	if r.OutboxFile == "" {
+		return
+	}
+	b, err := json.Marshal(r.outbox)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.OutboxFile), 0o700)
+	}
+	if err == nil {
+		tmp := r.OutboxFile + ".tmp"
+		if err = os.WriteFile(tmp, b, 0o600); err == nil {
+			err = os.Rename(tmp, r.OutboxFile)
+		}
+	}
+	if err != nil {
+		r.logf(logError, "outbox save failed: %v", err)
+	}
+}
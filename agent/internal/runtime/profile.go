@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// knownProfiles are the archetypes profilePrompt has guidance for.
+var knownProfiles = map[string]bool{"market_maker": true, "taker": true, "momentum": true, "arbitrage": true}
+
+type profileWeight struct {
+	name   string
+	weight float64
+}
+
+// parseProfileBlend parses a weighted spec such as
+// "market_maker:0.6,momentum:0.4" into weights normalized to sum to 1,
+// heaviest first. blend is false for a plain single profile name.
+func parseProfileBlend(spec string) (weights []profileWeight, blend bool, err error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if !strings.ContainsAny(spec, ":,") {
+		return nil, false, nil
+	}
+	seen := map[string]bool{}
+	total := 0.0
+	for _, part := range strings.Split(spec, ",") {
+		name, raw, ok := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, true, fmt.Errorf("profile blend entry %q must be name:weight", part)
+		}
+		if !knownProfiles[name] {
+			return nil, true, fmt.Errorf("unknown profile %q in blend", name)
+		}
+		if seen[name] {
+			return nil, true, fmt.Errorf("profile %q listed twice in blend", name)
+		}
+		seen[name] = true
+		weight, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, true, fmt.Errorf("profile %s weight %q is not a number", name, raw)
+		}
+		if weight < 0 {
+			return nil, true, fmt.Errorf("profile %s weight must not be negative (got %g)", name, weight)
+		}
+		total += weight
+		weights = append(weights, profileWeight{name: name, weight: weight})
+	}
+	if total <= 0 {
+		return nil, true, fmt.Errorf("profile blend weights must not all be zero")
+	}
+	kept := weights[:0]
+	for _, w := range weights {
+		if w.weight > 0 {
+			w.weight /= total
+			kept = append(kept, w)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].weight > kept[j].weight })
+	return kept, true, nil
+}
+
+// CheckProfile reports whether a requested profile spec is usable. Plain
+// names are always accepted; blends must parse with non-negative weights.
+func CheckProfile(spec string) error {
+	_, _, err := parseProfileBlend(spec)
+	return err
+}
+
+// PrimaryProfile is the heaviest profile in a blend, or the spec itself.
+func PrimaryProfile(spec string) string {
+	if weights, blend, err := parseProfileBlend(spec); blend && err == nil {
+		return weights[0].name
+	}
+	return spec
+}
+
+// canonicalBlend formats normalized weights as name:weight pairs.
+func canonicalBlend(weights []profileWeight) string {
+	parts := make([]string, len(weights))
+	for i, w := range weights {
+		parts[i] = fmt.Sprintf("%s:%.2f", w.name, w.weight)
+	}
+	return strings.Join(parts, ",")
+}
+
+// blendPrompt composes each profile's guidance, labelled with its weight.
+func blendPrompt(weights []profileWeight) string {
+	parts := make([]string, len(weights))
+	for i, w := range weights {
+		parts[i] = fmt.Sprintf("[%.0f%%] %s", w.weight*100, profilePrompt(w.name))
+	}
+	return "You blend several styles; favour each in proportion to its weight. " + strings.Join(parts, " ")
+}
+
+// hasProfile reports whether name is the runner's profile or part of its blend.
+func (r *Runner) hasProfile(name string) bool {
+	if weights, blend, err := parseProfileBlend(r.Profile); blend && err == nil {
+		for _, w := range weights {
+			if w.name == name {
+				return true
+			}
+		}
+		return false
+	}
+	return r.Profile == name
+}
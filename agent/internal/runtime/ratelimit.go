@@ -0,0 +1,47 @@
+package runtime
+
+import (
+	"time"
+)
+
+// takeActionToken spends one token from the MaxActionsPerMinute bucket. The
+// bucket holds a minute's worth of tokens and refills continuously. When it is
+// empty, it reports how long until the next token.
+func (r *Runner) takeActionToken(now time.Time) (bool, time.Duration) {
+	if r.MaxActionsPerMinute <= 0 {
+		return true, 0
+	}
+	capacity := float64(r.MaxActionsPerMinute)
+	perToken := time.Minute / time.Duration(r.MaxActionsPerMinute)
+	if r.actionTokensAt.IsZero() {
+		r.actionTokens = capacity
+	} else {
+		r.actionTokens += float64(now.Sub(r.actionTokensAt)) / float64(perToken)
+		if r.actionTokens > capacity {
+			r.actionTokens = capacity
+		}
+	}
+	r.actionTokensAt = now
+	if r.actionTokens < 1 {
+		return false, time.Duration((1 - r.actionTokens) * float64(perToken))
+	}
+	r.actionTokens--
+	return true, 0
+}
+
+// rateLimited turns action into a forced wait when the action bucket is empty,
+// returning how long to wait and whether the action was throttled.
+func (r *Runner) rateLimited(action *Action) (time.Duration, bool) {
+	allowed, wait := r.takeActionToken(r.now())
+	if allowed {
+		return 0, false
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	r.logf(logWarn, "rate limited: %d actions/min cap reached; dropping %s and waiting %s",
+		r.MaxActionsPerMinute, action.Action, wait.Round(time.Second))
+	action.Action = "wait"
+	action.Reason = "rate_limited"
+	return wait, true
+}
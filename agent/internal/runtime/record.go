@@ -0,0 +1,51 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// Snapshot is one cycle of recorded market data. Files are written as
+// <RecordDir>/snapshot-<unix_nanos>.json so a lexical sort replays them in
+// capture order.
+type Snapshot struct {
+	CapturedAt string            `json:"captured_at"`
+	AgentID    string            `json:"agent_id"`
+	Tokens     []indexer.Token   `json:"tokens"`
+	Offers     []indexer.Offer   `json:"offers"`
+	RFQs       []indexer.RFQ     `json:"rfqs"`
+	Balances   map[string]uint64 `json:"balances"`
+}
+
+// recordSnapshot writes the data fetched this cycle when RecordDir is set.
+// Failures are logged and never interrupt trading.
+func (r *Runner) recordSnapshot() {
+	if r.RecordDir == "" {
+		return
+	}
+	now := r.now().UTC()
+	snap := Snapshot{
+		CapturedAt: now.Format(time.RFC3339Nano),
+		AgentID:    r.AgentID,
+		Tokens:     r.lastTokens,
+		Offers:     r.lastOffers,
+		RFQs:       r.lastRFQs,
+		Balances:   r.lastBalances,
+	}
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(r.RecordDir, 0o700)
+	}
+	if err == nil {
+		path := filepath.Join(r.RecordDir, fmt.Sprintf("snapshot-%019d.json", now.UnixNano()))
+		err = os.WriteFile(path, b, 0o600)
+	}
+	if err != nil {
+		r.logf(logError, "record snapshot failed: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+const defaultRequoteDriftBps = 100
+
+// requoteStaleOffers cancels and reposts the agent's own open offers whose
+// price has drifted from the current reference by more than RequoteDriftBps.
+// It runs without the LLM and records each requote as a decision.
+func (r *Runner) requoteStaleOffers(ctx context.Context) {
+	if !r.AutoRequote || r.Indexer == nil || strings.TrimSpace(r.AgentID) == "" {
+		return
+	}
+	driftBps := r.RequoteDriftBps
+	if driftBps <= 0 {
+		driftBps = defaultRequoteDriftBps
+	}
+	requoted := 0
+	for i, offer := range r.lastOffers {
+		if requoted >= maxOpenOffersPerAgent {
+			return
+		}
+		if offer.AgentID != r.AgentID || !isOpenStatus(offer.Status) || offer.Qty <= 0 || offer.PriceAGC <= 0 {
+			continue
+		}
+		asset := strings.ToUpper(strings.TrimSpace(offer.Asset))
+		target := roundToTick(r.requoteReference(asset), r.tickSize(asset))
+		if target <= 0 {
+			continue
+		}
+		drift := math.Abs(offer.PriceAGC-target) / target * 10000
+		if drift <= driftBps {
+			continue
+		}
+		requoted++
+		action := Action{
+			Action:      "requote",
+			AssetSymbol: asset,
+			Category:    offer.Category,
+			PriceAGC:    target,
+			Qty:         offer.Qty,
+			Reason:      fmt.Sprintf("auto_requote %s from %.4f (drift %.0f bps)", offer.OfferID, offer.PriceAGC, drift),
+		}
+		if err := r.cancelAndRepost(ctx, offer, target); err != nil {
+			r.postDecision(ctx, action, "rejected", err.Error(), "")
+			r.logf(logError, "requote failed: %v", err)
+			continue
+		}
+		r.lastOffers[i].PriceAGC = target
+		r.invalidateBalances()
+		r.postDecision(ctx, action, "executed", "", "")
+		r.logf(logInfo, "requoted %s %s: %.4f -> %.4f", asset, offer.OfferID, offer.PriceAGC, target)
+	}
+}
+
+// requoteReference is the best competing ask for the asset, falling back to
+// the token's last price when nobody else is offering.
+func (r *Runner) requoteReference(asset string) float64 {
+	best := 0.0
+	for _, offer := range r.lastOffers {
+		if offer.AgentID == r.AgentID || !isOpenStatus(offer.Status) || offer.Qty <= 0 || offer.PriceAGC <= 0 {
+			continue
+		}
+		if strings.ToUpper(strings.TrimSpace(offer.Asset)) != asset {
+			continue
+		}
+		if best == 0 || offer.PriceAGC < best {
+			best = offer.PriceAGC
+		}
+	}
+	if best > 0 {
+		return best
+	}
+	return r.lastTokenPrice[asset]
+}
+
+func (r *Runner) cancelAndRepost(ctx context.Context, offer indexer.Offer, price float64) error {
+	asset := strings.ToUpper(strings.TrimSpace(offer.Asset))
+	execCtx, cancel := context.WithTimeout(ctx, r.Timeouts.PostAction)
+	defer cancel()
+	if err := r.Indexer.PostDevAction(execCtx, indexer.DevActionRequest{
+		Action:      "cancel",
+		AgentID:     r.AgentID,
+		AssetSymbol: asset,
+		OfferID:     offer.OfferID,
+		Reason:      "auto_requote",
+	}); err != nil {
+		return fmt.Errorf("cancel %s: %w", offer.OfferID, err)
+	}
+	if err := r.Indexer.PostDevAction(execCtx, indexer.DevActionRequest{
+		Action:      "post_offer",
+		AgentID:     r.AgentID,
+		AssetSymbol: asset,
+		Category:    strings.TrimSpace(offer.Category),
+		PriceAGC:    price,
+		Qty:         offer.Qty,
+		Reason:      "auto_requote",
+		TTLSec:      r.orderTTL(0),
+	}); err != nil {
+		return fmt.Errorf("repost %s: %w", asset, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+func TestRequoteStaleOffer(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		price   float64
+		actions []string
+	}{
+		{name: "outside band", price: 2.6, actions: []string{"cancel", "post_offer"}},
+		{name: "inside band", price: 2.31, actions: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, stub := newTestRunner(t, `{"action":"wait","next_check_sec":1,"reason":"test"}`)
+			stub.SetOffers(
+				indexer.Offer{OfferID: "offer-9", AgentID: "agent-9", Asset: "FOO", PriceAGC: 2.3, Qty: 10, Status: "open"},
+				indexer.Offer{OfferID: "offer-1", AgentID: testAgentID, Asset: "FOO", PriceAGC: tc.price, Qty: 4, Status: "open"},
+			)
+			r.AutoRequote = true
+			r.decide(context.Background())
+
+			got := stub.Actions()
+			if len(got) != len(tc.actions) {
+				t.Fatalf("got %d actions %+v, want %v", len(got), got, tc.actions)
+			}
+			for i, want := range tc.actions {
+				if got[i].Action != want {
+					t.Fatalf("action %d is %q, want %q", i, got[i].Action, want)
+				}
+			}
+			if len(got) == 0 {
+				return
+			}
+			if got[0].OfferID != "offer-1" {
+				t.Fatalf("cancelled offer %q, want offer-1", got[0].OfferID)
+			}
+			if repost := got[1]; repost.AssetSymbol != "FOO" || repost.PriceAGC != 2.3 || repost.Qty != 4 {
+				t.Fatalf("reposted %s %v x %v, want FOO 2.3 x 4", repost.AssetSymbol, repost.PriceAGC, repost.Qty)
+			}
+		})
+	}
+}
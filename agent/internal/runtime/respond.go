@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// incomingRFQs lists the open RFQs other agents addressed to this agent.
+func (r *Runner) incomingRFQs() []indexer.RFQ {
+	out := []indexer.RFQ{}
+	for _, rfq := range r.lastRFQs {
+		if rfq.AgentID == r.AgentID || !isOpenStatus(rfq.Status) || rfq.Qty <= 0 {
+			continue
+		}
+		if target := strings.TrimSpace(rfq.TargetAgentID); target != "" && target == r.AgentID {
+			out = append(out, rfq)
+		}
+	}
+	return out
+}
+
+// incomingRFQSummary describes the quote requests the model may answer with
+// respond_rfq ("" when responding is off or there are none).
+func (r *Runner) incomingRFQSummary() string {
+	if !r.actionPermitted("respond_rfq") {
+		return ""
+	}
+	parts := []string{}
+	for _, rfq := range r.incomingRFQs() {
+		asset := strings.ToUpper(strings.TrimSpace(rfq.Asset))
+		parts = append(parts, fmt.Sprintf("rfq %s from %s: %s q=%.2f max=%.2f (you hold %d)",
+			rfq.RFQID, rfq.AgentID, asset, rfq.Qty, rfq.MaxPriceAGC, r.lastBalances[asset]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (r *Runner) findIncomingRFQ(id string) (indexer.RFQ, bool) {
+	for _, rfq := range r.incomingRFQs() {
+		if rfq.RFQID == id {
+			return rfq, true
+		}
+	}
+	return indexer.RFQ{}, false
+}
+
+// repairRespond fills a respond_rfq's asset, side and missing qty from the
+// request it answers; qty defaults to as much of it as the agent holds.
+func (r *Runner) repairRespond(action *Action) {
+	rfq, ok := r.findIncomingRFQ(action.RFQID)
+	if !ok {
+		return
+	}
+	action.Side = "sell"
+	if strings.TrimSpace(action.AssetSymbol) == "" {
+		action.AssetSymbol = strings.ToUpper(strings.TrimSpace(rfq.Asset))
+	}
+	if action.Qty <= 0 {
+		action.Qty = math.Min(rfq.Qty, float64(r.lastBalances[strings.ToUpper(strings.TrimSpace(rfq.Asset))]))
+	}
+}
+
+// preflightRespond checks a quote against the request it answers: it must be
+// open and addressed to this agent, for the same asset, within its qty and
+// max price, and covered by the agent's holding.
+func (r *Runner) preflightRespond(action Action, asset string, qty uint64) (string, string) {
+	if action.RFQID == "" {
+		return "blocked", "rfq_id missing"
+	}
+	rfq, ok := r.findIncomingRFQ(action.RFQID)
+	if !ok {
+		return "blocked", "no open rfq " + action.RFQID + " addressed to this agent"
+	}
+	if strings.ToUpper(strings.TrimSpace(rfq.Asset)) != asset {
+		return "blocked", "asset does not match rfq"
+	}
+	if float64(qty) > rfq.Qty {
+		return "blocked", "qty exceeds rfq qty"
+	}
+	if action.PriceAGC <= 0 {
+		return "blocked", "price must be positive"
+	}
+	if rfq.MaxPriceAGC > 0 && action.PriceAGC > rfq.MaxPriceAGC {
+		return "blocked", "quote above rfq max price"
+	}
+	if r.lastBalances[asset] < qty {
+		return "blocked", "insufficient asset balance"
+	}
+	if r.spendableAGC() < calcTradeFee(uint64(math.Round(action.PriceAGC*float64(qty)))) {
+		return "blocked", "insufficient " + r.settlement() + " for fee"
+	}
+	return "", ""
+}
@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// RFQ fill modes for create_rfq sizes larger than the visible offers at or
+// below the RFQ price could fill.
+const (
+	rfqFillAllow = "allow" // post as asked and log the shortfall
+	rfqFillClamp = "clamp" // shrink qty to the fillable size
+	rfqFillBlock = "block" // reject the RFQ in preflight
+)
+
+func (r *Runner) rfqFillMode() string {
+	mode := strings.ToLower(strings.TrimSpace(r.RFQFillMode))
+	if mode == "" {
+		return rfqFillAllow
+	}
+	return mode
+}
+
+// rfqFillable is how much of an RFQ for asset at price other agents' open
+// offers could fill right now (price <= 0 = no price cap).
+func (r *Runner) rfqFillable(asset string, price float64) float64 {
+	return r.simulateFill("buy", strings.ToUpper(strings.TrimSpace(asset)), price, 0).Qty
+}
+
+// sizeRFQ applies RFQFillMode to a create_rfq whose qty exceeds the fillable
+// size and returns a note describing the shortfall or adjustment ("" if
+// none). Blocking is left to preflightRFQFill, as is an RFQ nothing could
+// fill, which clamp mode cannot shrink to a whole unit.
+func (r *Runner) sizeRFQ(action *Action) string {
+	if strings.ToLower(strings.TrimSpace(action.Action)) != "create_rfq" || r.rfqFillMode() == rfqFillBlock {
+		return ""
+	}
+	qty := math.Round(action.Qty)
+	fillable := math.Floor(r.rfqFillable(action.AssetSymbol, action.PriceAGC))
+	if qty <= 0 || fillable >= qty {
+		return ""
+	}
+	if r.rfqFillMode() == rfqFillClamp && fillable >= 1 {
+		action.Qty = fillable
+		return fmt.Sprintf("rfq size clamped from %.0f to fillable %.0f", qty, fillable)
+	}
+	return fmt.Sprintf("only %.0f of %.0f fillable from visible offers", fillable, qty)
+}
+
+// preflightRFQFill blocks, in block mode, RFQs larger than visible offers at
+// or below their price could fill.
+func (r *Runner) preflightRFQFill(asset string, price float64, qty uint64) (string, string) {
+	if r.rfqFillMode() != rfqFillBlock {
+		return "", ""
+	}
+	if fillable := math.Floor(r.rfqFillable(asset, price)); fillable < float64(qty) {
+		return "blocked", fmt.Sprintf("rfq qty %d exceeds fillable %.0f", qty, fillable)
+	}
+	return "", ""
+}
+
+// rfqFillNote tells the model RFQs are sized against visible offers.
+func (r *Runner) rfqFillNote() string {
+	switch r.rfqFillMode() {
+	case rfqFillClamp:
+		return "RFQs larger than the offers at or below their price are cut down to the fillable size; size create_rfq from the fillable buy amounts"
+	case rfqFillBlock:
+		return "RFQs larger than the offers at or below their price are blocked; size create_rfq within the fillable buy amounts"
+	}
+	return "RFQs only fill against offers at or below their price; size create_rfq near the fillable buy amounts so it does not sit mostly unfilled"
+}
@@ -2,27 +2,40 @@ package runtime
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"math"
+	"math/rand"
+	"os"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"agentmarket/agent/internal/indexer"
 	"agentmarket/agent/internal/llm"
 )
 
 type Action struct {
-	Action       string  `json:"action"`
-	AssetSymbol  string  `json:"asset_symbol"`
-	Category     string  `json:"category"`
-	PriceAGC     float64 `json:"price_agc"`
-	Qty          float64 `json:"qty"`
-	Side         string  `json:"side"`
-	Reason       string  `json:"reason"`
-	NextCheckSec int     `json:"next_check_sec"`
+	Action       string   `json:"action"`
+	AssetSymbol  string   `json:"asset_symbol"`
+	Category     string   `json:"category"`
+	PriceAGC     float64  `json:"price_agc"`
+	Qty          float64  `json:"qty"`
+	Side         string   `json:"side"`
+	Reason       string   `json:"reason"`
+	NextCheckSec int      `json:"next_check_sec"`
+	Confidence   *float64 `json:"confidence,omitempty"`
+	OfferID      string   `json:"offer_id,omitempty"`
+	RFQID        string   `json:"rfq_id,omitempty"`
+	TTLSec       int      `json:"ttl_sec,omitempty"`
+	Watch        *Watch   `json:"watch,omitempty"`
 }
 
 const (
@@ -30,13 +43,44 @@ const (
 	maxOpenOffersPerAsset = 3
 	maxOpenRFQsPerAgent   = 3
 	decisionMaxAttempts   = 3
+	minRetryTemperature   = 0.05
+	maxRetryOutputTokens  = 4096
 	decisionMemoryLimit   = 12
 	decisionSeedLimit     = 8
+	promptMemoryEntries   = 6
+	promptBookRows        = 5
 	defaultWaitSec        = 6
 	minWaitSec            = 1
 	maxWaitSec            = 60
+	thinVolumeFraction    = 0.1
+	defaultReasonChars    = 280
+	decisionRawLimit      = 2048
+	maxIndexerBackoff     = 60 * time.Second
+	pnlRewardWeight       = 5.0
+	maxPnLReward          = 0.5
 )
 
+// Runner states reported in heartbeats.
+const (
+	stateDeciding  = "deciding"
+	stateWaiting   = "waiting"
+	stateExecuting = "executing"
+	stateCooldown  = "cooldown"
+	statePaused    = "paused"
+	stateHalted    = "halted"
+)
+
+// actionMsgTypes maps executable actions to the chain message they emit,
+// for enforcement against the agent's allowed_msgs policy.
+var actionMsgTypes = map[string]string{
+	"post_offer":  "MsgPostOffer",
+	"create_rfq":  "MsgCreateRFQ",
+	"trade":       "MsgTrade",
+	"cancel":      "MsgCancelOrder",
+	"respond_rfq": "MsgRespondRFQ",
+	"mint":        "MsgMint",
+}
+
 var (
 	offerFeeAGC                uint64 = 0
 	rfqFeeAGC                  uint64 = 0
@@ -44,26 +88,223 @@ var (
 	syntheticMintFeePerUnitAGC uint64 = 0
 )
 
+// Runner fields are owned by the goroutine calling Run. Other goroutines
+// must go through Pause, Resume, DecideNow, SetStrategyPrompt, Status and
+// WriteMetrics, which only touch the mutex-guarded control state.
 type Runner struct {
 	Tick           time.Duration
 	AgentID        string
 	UserAddr       string
 	LLM            llm.Client
-	Indexer        *indexer.Client
+	Indexer        indexer.MarketData
 	Profile        string
 	StrategyPrompt string
-	lastBalances   map[string]uint64
-	lastTokenPrice map[string]float64
-	lastOffers     []indexer.Offer
-	lastRFQs       []indexer.RFQ
-	lastOpenOffers int
-	lastOpenRFQs   int
-	lastOffersByAS map[string]int
-	allowedTokens  []string
-	lastAgentSync  time.Time
-	cycle          uint64
-	decisionMemory []memoryDecision
-	memorySeeded   bool
+	// StrategyPromptFile, when set, replaces the registered strategy prompt
+	// with the file's contents, re-read whenever it changes.
+	StrategyPromptFile string
+	MaxReasonChars     int
+	MaxRawChars        int
+	AssetCooldown      time.Duration
+	MinConfidence      float64
+	DefaultConfidence  float64
+	AutoRequote        bool
+	RequoteDriftBps    float64
+	AllowedMsgs        []string
+	// AllowedCounterparties/DeniedCounterparties restrict which agents' orders
+	// the runner sees and fills against, on top of the on-chain policy lists.
+	AllowedCounterparties []string
+	DeniedCounterparties  []string
+	Timeouts              Timeouts
+	BalancesTTL           time.Duration
+	// SettlementSymbol is the market's base currency, which is never traded
+	// as an asset and pays for everything (empty = AGC).
+	SettlementSymbol string
+	// MinAGCReserve is settlement currency that preflight treats as unspendable.
+	MinAGCReserve uint64
+	// MinVolume24H marks tokens trading less than this over 24h as thin in
+	// the orderbook lens (0 = a tenth of the median listed volume).
+	MinVolume24H float64
+	// PriceBandBps blocks priced actions further than this from the mark (0 = off).
+	PriceBandBps float64
+	// SpreadBps is the market maker's target bid/ask spread around the mark,
+	// overridden per asset symbol by AssetSpreadBps (0 = model's choice).
+	SpreadBps      float64
+	AssetSpreadBps map[string]float64
+	// TickSize is the price increment model prices are rounded to, overridden
+	// per asset symbol by AssetTickSize (0 = no rounding).
+	TickSize      float64
+	AssetTickSize map[string]float64
+	// ReduceOnly restricts the agent to selling down existing holdings; the
+	// on-chain policy can also switch it on.
+	ReduceOnly bool
+	// OrderTTLSec is the default and maximum ttl_sec for posted offers and
+	// RFQs (0 = no expiry unless the model asks for one).
+	OrderTTLSec int
+	// HeartbeatInterval spaces liveness heartbeats independently of Tick
+	// (0 = one per tick). State changes still heartbeat immediately.
+	HeartbeatInterval time.Duration
+	// MaxOrderEquityFraction caps each order's notional at this share of
+	// equity (AGC plus marked holdings); larger orders are scaled down (0 = off).
+	MaxOrderEquityFraction float64
+	// MinNotionalAGC blocks trades, offers and RFQs worth less than this
+	// (price x qty, in the settlement asset) as dust (0 = off).
+	MinNotionalAGC float64
+	// MaxActionsPerMinute caps executed actions with a token bucket; once it
+	// is empty the model's choice is replaced by a wait (0 = no cap).
+	MaxActionsPerMinute int
+	// MinWait and MaxWait bound the model's next_check_sec (0 = 1s and 60s).
+	MinWait time.Duration
+	MaxWait time.Duration
+	// ConfirmFills makes executed trades wait up to this long for their fills
+	// to appear in the agent's trades, recording the filled price and qty, or
+	// status executed_unconfirmed if none show up (0 = record on acceptance).
+	ConfirmFills time.Duration
+	// StalePriceAfter flags tokens whose last trade is older than this as
+	// stale in the prompt; BlockStalePrices also blocks orders on them in
+	// preflight (0 = off).
+	StalePriceAfter  time.Duration
+	BlockStalePrices bool
+	// StrictStrategyVersion holds all actions while the registered strategy
+	// version is outside the supported range; otherwise it only warns.
+	StrictStrategyVersion bool
+	// MaxConsecutiveWaits escalates the prompt once the model has waited this
+	// many cycles in a row without an executed action (0 = off). With
+	// WaitFallback, a further wait is replaced by a one-unit heuristic trade.
+	MaxConsecutiveWaits int
+	WaitFallback        bool
+	// RespondRFQs lets the model answer RFQs other agents address to it with
+	// respond_rfq quotes (also subject to AllowedMsgs as MsgRespondRFQ).
+	RespondRFQs bool
+	// RFQFillMode handles create_rfq sizes beyond what visible offers at or
+	// below the RFQ price could fill: allow (default, log only), clamp or block.
+	RFQFillMode string
+	// MaxMintQty caps the qty of one mint action and is its default qty
+	// (0 = no cap, default 1).
+	MaxMintQty uint64
+	// Temperature is the sampling temperature the LLM client was built with.
+	// Each decideStrict retry halves it to steer the model back to the schema
+	// (0 = leave the client's setting alone).
+	Temperature float64
+	// DecisionTimeout bounds a whole decideStrict call, retries included
+	// (0 = only the per-call LLM client timeout applies).
+	DecisionTimeout time.Duration
+	// Verbose prints every prompt sent to the LLM and raises LogLevel to
+	// debug (the --verbose flag).
+	Verbose bool
+	// LogLevel gates runtime log lines: error, warn, info (the default) or
+	// debug, which adds each attempt's raw model output. Verbose overrides it
+	// with debug.
+	LogLevel string
+	// StrictParsing rejects model output with unknown or mistyped action
+	// fields, retrying with the decode error, instead of dropping them.
+	StrictParsing bool
+	// ContextTokens is the prompt budget in tokens; buildPrompt trims decision
+	// memory, then orderbook lens rows, to stay within 90% of it (0 = the
+	// model's known context window, or no trimming for unknown models).
+	ContextTokens int
+	// NoStartJitter makes the first decision immediate instead of after a
+	// random delay of up to one Tick (which spreads out fleet startups).
+	NoStartJitter bool
+	// WarmupCycles and Warmup hold the runner in observe-only mode after
+	// start until both have elapsed: data is fetched but no LLM call or action.
+	WarmupCycles int
+	Warmup       time.Duration
+	// PauseFile, when it exists, stops decisions and actions until removed.
+	PauseFile string
+	// OperatorURL, when set, arms a dead-man's switch: it is polled every
+	// OperatorPing (0 = 30s) and if no 2xx answer arrives for OperatorTimeout
+	// (0 = 5m) the runner pauses, first closing all orders when
+	// OperatorCloseAll is set.
+	OperatorURL      string
+	OperatorPing     time.Duration
+	OperatorTimeout  time.Duration
+	OperatorCloseAll bool
+	// OutboxSize caps how many failed decision/heartbeat posts are kept for
+	// retry; the oldest are dropped beyond it (0 = 256). OutboxFile, when
+	// set, persists them across restarts.
+	OutboxSize int
+	OutboxFile string
+	// Clock supplies timestamps for decision memory, cooldowns and scheduling
+	// (nil = system clock).
+	Clock Clock
+	// RecordDir, when set, receives a JSON Snapshot of the market data
+	// fetched each cycle.
+	RecordDir string
+	// MaxDecisions caps LLM-invoking cycles per session (0 = unlimited).
+	// Once reached the runner only heartbeats, or returns when ExitOnMaxDecisions is set.
+	MaxDecisions         int
+	ExitOnMaxDecisions   bool
+	lastBalances         map[string]uint64
+	lastBalancesAt       time.Time
+	lastTokenPrice       map[string]float64
+	lastTokens           []indexer.Token
+	lastOffers           []indexer.Offer
+	lastRFQs             []indexer.RFQ
+	lastOpenOffers       int
+	lastOpenRFQs         int
+	lastOffersByAS       map[string]int
+	allowedTokens        []string
+	policyAllowedTokens  []string
+	missingTokens        []string
+	policyAllowedCPs     []string
+	policyDeniedCPs      []string
+	policyReduceOnly     bool
+	policyMintTokens     []string
+	lastAgentSync        time.Time
+	rejectedStrategyHash string
+	cycle                uint64
+	decisionMemory       []memoryDecision
+	memorySeeded         bool
+	cooldownUntil        map[string]time.Time
+	metrics              Metrics
+	indexerFailures      int
+	decisionCount        int
+	state                string
+	nextDecisionAt       time.Time
+	startedAt            time.Time
+	observeCycles        int
+	paused               bool
+	actionTokens         float64
+	actionTokensAt       time.Time
+	outbox               []outboxItem
+	outboxFailures       int
+	outboxRetryAt        time.Time
+	operatorPingAt       time.Time
+	operatorContactAt    time.Time
+	operatorLost         bool
+	watch                *Watch
+	halted               bool
+	noLLMCycles          int
+	consecutiveWaits     int
+	tokensLoaded         bool
+	lastSignals          map[string]indexer.DecisionMetadata
+	strategyVersion      string
+	warnedVersion        string
+	localPrompt          string
+	localPromptMod       time.Time
+	localPromptErr       string
+	watchNote            string
+	ctrl                 control
+	rng                  *rand.Rand
+}
+
+// Timeouts bounds each class of indexer call made by the runner.
+type Timeouts struct {
+	Fetch        time.Duration
+	Agent        time.Duration
+	PostAction   time.Duration
+	PostDecision time.Duration
+	Heartbeat    time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Fetch:        3 * time.Second,
+		Agent:        2 * time.Second,
+		PostAction:   5 * time.Second,
+		PostDecision: 3 * time.Second,
+		Heartbeat:    2 * time.Second,
+	}
 }
 
 type memoryDecision struct {
@@ -77,99 +318,297 @@ type memoryDecision struct {
 	Reason      string
 	CreatedAt   string
 	Reward      float64
+	// RequestID ties the entry to the X-Request-ID sent for the decision.
+	RequestID string
+	// Market is the asset's orderbook signal when the decision was made.
+	Market *indexer.DecisionMetadata
 }
 
 func NewRunner(agentID string, client llm.Client, idx *indexer.Client) *Runner {
 	return &Runner{
-		Tick:           2 * time.Second,
-		AgentID:        agentID,
-		LLM:            client,
-		Indexer:        idx,
-		Profile:        resolveProfile(agentID, ""),
-		lastTokenPrice: map[string]float64{},
-		lastOffersByAS: map[string]int{},
+		Tick:              2 * time.Second,
+		AgentID:           agentID,
+		LLM:               client,
+		Indexer:           marketData(idx),
+		Profile:           ResolveProfile(agentID, ""),
+		DefaultConfidence: 1,
+		Timeouts:          DefaultTimeouts(),
+		lastTokenPrice:    map[string]float64{},
+		lastOffersByAS:    map[string]int{},
+		cooldownUntil:     map[string]time.Time{},
+		rng:               rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
 func NewRunnerWithProfile(agentID, userAddr string, client llm.Client, idx *indexer.Client, profile string) *Runner {
 	return &Runner{
-		Tick:           2 * time.Second,
-		AgentID:        agentID,
-		UserAddr:       strings.TrimSpace(userAddr),
-		LLM:            client,
-		Indexer:        idx,
-		Profile:        resolveProfile(agentID, profile),
-		lastTokenPrice: map[string]float64{},
-		lastOffersByAS: map[string]int{},
+		Tick:              2 * time.Second,
+		AgentID:           agentID,
+		UserAddr:          strings.TrimSpace(userAddr),
+		LLM:               client,
+		Indexer:           marketData(idx),
+		Profile:           ResolveProfile(agentID, profile),
+		DefaultConfidence: 1,
+		Timeouts:          DefaultTimeouts(),
+		lastTokenPrice:    map[string]float64{},
+		lastOffersByAS:    map[string]int{},
+		cooldownUntil:     map[string]time.Time{},
+		rng:               rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// marketData keeps a nil *indexer.Client a nil MarketData, so the runner's
+// "no indexer configured" checks still hold.
+func marketData(idx *indexer.Client) indexer.MarketData {
+	if idx == nil {
+		return nil
 	}
+	return idx
 }
 
 func (r *Runner) Run(ctx context.Context) error {
 	ticker := time.NewTicker(r.Tick)
 	defer ticker.Stop()
+	r.loadOutbox()
+	defer r.publishStatus()
+	r.state = stateWaiting
+	r.startedAt = r.now()
+	r.nextDecisionAt = r.startedAt
+	if !r.NoStartJitter && r.Tick > 0 {
+		r.nextDecisionAt = r.nextDecisionAt.Add(time.Duration(r.rng.Int63n(int64(r.Tick))))
+	}
 	r.postHeartbeat(ctx)
-	nextDecisionAt := time.Now()
+	heartbeatEvery := r.Tick
+	heartbeat := time.NewTimer(heartbeatEvery)
+	if r.HeartbeatInterval > 0 {
+		heartbeatEvery = r.HeartbeatInterval
+		// A per-agent offset keeps a fleet from heartbeating in lockstep.
+		heartbeat.Reset(heartbeatEvery + time.Duration(r.rng.Int63n(int64(heartbeatEvery)/5+1)))
+	}
+	defer heartbeat.Stop()
+	decideNow := r.decideNowChan()
+	r.publishStatus()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-ticker.C:
-			r.cycle++
+		case <-heartbeat.C:
 			r.postHeartbeat(ctx)
-			if time.Now().Before(nextDecisionAt) {
-				continue
-			}
-			if r.LLM == nil {
-				r.postDecision(ctx, Action{Action: "invalid", Reason: "no_llm"}, "rejected", "no llm configured", "")
-				nextDecisionAt = time.Now().Add(5 * time.Second)
-				continue
+			heartbeat.Reset(heartbeatEvery)
+		case <-decideNow:
+			if r.decide(ctx) {
+				return nil
 			}
-			r.refreshBalances(ctx)
-			r.seedDecisionMemory(ctx)
-			prompt := r.buildPrompt(ctx)
-			action, raw, err := r.decideStrict(ctx, prompt)
-			if err != nil {
-				fmt.Printf("strict decision error (%s/%s): %v\n", r.LLM.Provider(), r.LLM.Model(), err)
-				r.postDecision(ctx, Action{Action: "invalid", Reason: "decision_error"}, "rejected", err.Error(), raw)
-				nextDecisionAt = time.Now().Add(3 * time.Second)
+		case <-ticker.C:
+			r.cycle++
+			r.drainOutbox(ctx)
+			r.checkOperator(ctx)
+			if r.now().Before(r.nextDecisionAt) && !r.watchTriggered(ctx) {
+				r.publishStatus()
 				continue
 			}
-			if strings.EqualFold(action.Action, "wait") {
-				if strings.TrimSpace(action.Reason) == "" {
-					action.Reason = "model_wait"
-				}
-				waitFor := normalizeWaitDuration(action.NextCheckSec)
-				r.postDecision(ctx, action, "wait", "", raw)
-				nextDecisionAt = time.Now().Add(waitFor)
-				continue
+			if r.decide(ctx) {
+				return nil
 			}
-			r.executeAction(ctx, action, raw)
-			nextDecisionAt = time.Now().Add(r.Tick)
 		}
 	}
 }
 
+// decide runs one decision cycle unless the decision cap is reached, then
+// schedules the next one. It reports whether Run should return.
+func (r *Runner) decide(ctx context.Context) bool {
+	defer r.publishStatus()
+	if r.decisionCapReached() {
+		if r.ExitOnMaxDecisions {
+			r.logf(logInfo, "decision cap reached (%d); exiting", r.MaxDecisions)
+			return true
+		}
+		return false
+	}
+	delay := r.runCycle(ctx)
+	if r.state != statePaused && r.state != stateHalted {
+		r.state = stateWaiting
+	}
+	if backoff := r.indexerBackoff(); backoff > delay {
+		delay = backoff
+		r.state = stateCooldown
+	}
+	r.nextDecisionAt = r.now().Add(delay)
+	return false
+}
+
+// runCycle makes one decision and returns how long to wait before the next.
+func (r *Runner) runCycle(ctx context.Context) time.Duration {
+	r.watch = nil
+	if r.pauseRequested() {
+		r.setState(ctx, statePaused)
+		return r.Tick
+	}
+	ctx = indexer.WithRequestID(ctx, newRequestID())
+	if r.marketHalted(ctx) {
+		r.setState(ctx, stateHalted)
+		return r.Tick
+	}
+	if r.LLM == nil {
+		r.postDecision(ctx, Action{Action: "invalid", Reason: "no_llm"}, "rejected", "no llm configured", "")
+		r.noLLMCycles++
+		return noLLMBackoff(r.noLLMCycles)
+	}
+	r.setState(ctx, stateDeciding)
+	r.refreshBalances(ctx)
+	r.seedDecisionMemory(ctx)
+	prompt := r.buildPrompt(ctx)
+	r.recordSnapshot()
+	if r.warmingUp() {
+		r.observeCycles++
+		r.postDecision(ctx, Action{Action: "wait", Reason: "warmup"}, "observe", "", "")
+		return r.Tick
+	}
+	if r.strategyVersionBlocked() {
+		r.postDecision(ctx, Action{Action: "wait", Reason: "unsupported_strategy_version"}, "wait", "", "")
+		return r.Tick
+	}
+	if reason := r.insufficientData(); reason != "" {
+		r.postDecision(ctx, Action{Action: "wait", Reason: reason}, "wait", "", "")
+		return r.waitDuration(0)
+	}
+	r.requoteStaleOffers(ctx)
+	r.decisionCount++
+	if r.decisionCapReached() {
+		r.logf(logInfo, "decision cap reached (%d); no further llm calls this session", r.MaxDecisions)
+	}
+	decideCtx := ctx
+	if r.DecisionTimeout > 0 {
+		var cancel context.CancelFunc
+		decideCtx, cancel = context.WithTimeout(ctx, r.DecisionTimeout)
+		defer cancel()
+	}
+	action, raw, err := r.decideStrict(decideCtx, prompt)
+	if err != nil {
+		r.logf(logError, "strict decision error [%s] (%s/%s): %v", indexer.RequestID(ctx), r.LLM.Provider(), r.LLM.Model(), err)
+		r.postDecision(ctx, Action{Action: "invalid", Reason: "decision_error"}, "rejected", err.Error(), raw)
+		return 3 * time.Second
+	}
+	// With a fallback chain the served provider may differ per decision.
+	r.logf(logInfo, "decision [%s] %s served by %s/%s", indexer.RequestID(ctx), action.Action, r.LLM.Provider(), r.LLM.Model())
+	if strings.EqualFold(action.Action, "wait") && r.WaitFallback && r.waitLimitReached() {
+		if fallback, ok := r.heuristicAction(); ok {
+			r.logf(logInfo, "model waited %d cycles in a row; falling back to %s %s %s", r.consecutiveWaits, fallback.Action, fallback.Side, fallback.AssetSymbol)
+			action = fallback
+		}
+	}
+	if strings.EqualFold(action.Action, "wait") {
+		if strings.TrimSpace(action.Reason) == "" {
+			action.Reason = "model_wait"
+		}
+		r.consecutiveWaits++
+		r.watch = action.Watch
+		r.postDecision(ctx, action, "wait", "", raw)
+		return r.waitDuration(action.NextCheckSec)
+	}
+	if r.belowConfidence(action) {
+		action.Action = "wait"
+		action.Reason = "low_confidence"
+		r.consecutiveWaits++
+		r.postDecision(ctx, action, "wait", "", raw)
+		return r.waitDuration(action.NextCheckSec)
+	}
+	if wait, limited := r.rateLimited(&action); limited {
+		r.postDecision(ctx, action, "rate_limited", "", raw)
+		return wait
+	}
+	r.setState(ctx, stateExecuting)
+	r.executeAction(ctx, action, raw)
+	return r.Tick
+}
+
+// newRequestID returns a random 16-hex-digit id for one decision cycle.
+func newRequestID() string {
+	var b [8]byte
+	_, _ = cryptorand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// pauseRequested checks the kill-switch file and logs transitions.
+func (r *Runner) pauseRequested() bool {
+	reason := ""
+	if r.controlPaused() {
+		reason = "control API request"
+	} else if r.operatorLost {
+		reason = "operator unreachable (dead-man's switch)"
+	} else if path := strings.TrimSpace(r.PauseFile); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			reason = path + " present"
+		}
+	}
+	paused := reason != ""
+	if paused != r.paused {
+		if paused {
+			r.logf(logInfo, "paused: %s; heartbeating only until resumed", reason)
+		} else {
+			r.logf(logInfo, "resumed")
+		}
+		r.paused = paused
+	}
+	return paused
+}
+
+func (r *Runner) warmingUp() bool {
+	if r.observeCycles < r.WarmupCycles {
+		return true
+	}
+	return r.Warmup > 0 && r.now().Sub(r.startedAt) < r.Warmup
+}
+
+// SetSeed makes the runner's random choices reproducible.
+func (r *Runner) SetSeed(seed int64) {
+	r.rng = rand.New(rand.NewSource(seed))
+}
+
 func (r *Runner) decideStrict(ctx context.Context, basePrompt llm.Prompt) (Action, string, error) {
 	prompt := basePrompt
 	lastRaw := ""
 	lastErr := "no decision produced"
+	// maxTokens raises the output limit after a truncated reply (0 = the
+	// client's own limit).
+	maxTokens := 0
 
+	// Each Generate call inherits ctx, so its effective timeout is the smaller
+	// of the remaining decision budget and the client's own timeout.
 	for attempt := 1; attempt <= decisionMaxAttempts; attempt++ {
-		response, err := r.LLM.Generate(ctx, prompt)
-		if err != nil {
+		if ctx.Err() != nil {
+			return Action{}, lastRaw, fmt.Errorf("decision deadline exceeded after %d attempts: %s", attempt-1, lastErr)
+		}
+		if r.Verbose {
+			r.logf(logDebug, "llm prompt attempt %d [%s]:\n[system]\n%s\n[user]\n%s", attempt, indexer.RequestID(ctx), prompt.System, prompt.User)
+		}
+		r.metrics.LLMRequests++
+		opts := r.retryOptions(attempt)
+		opts.MaxOutputTokens = maxTokens
+		response, err := r.LLM.GenerateWithOptions(ctx, prompt, opts)
+		var truncated *llm.TruncatedError
+		if errors.As(err, &truncated) {
+			r.metrics.LLMErrors++
+			lastRaw = truncated.Partial
+			lastErr = "response truncated at the output token limit; reply with the bare JSON object and a short reason"
+			if raised := min(truncated.MaxOutputTokens*2, maxRetryOutputTokens); raised > truncated.MaxOutputTokens {
+				maxTokens = raised
+			}
+		} else if err != nil {
+			r.metrics.LLMErrors++
 			lastErr = fmt.Sprintf("llm error: %v", err)
 		} else {
 			raw := strings.TrimSpace(response)
 			lastRaw = raw
-			fmt.Printf("llm decision attempt %d (%s/%s): %s\n", attempt, r.LLM.Provider(), r.LLM.Model(), raw)
-			action, parseErr := parseAction(raw)
+			r.logf(logDebug, "llm decision attempt %d [%s] (%s/%s): %s", attempt, indexer.RequestID(ctx), r.LLM.Provider(), r.LLM.Model(), raw)
+			action, parseErr := parseAction(raw, r.StrictParsing)
 			if parseErr != nil {
 				lastErr = fmt.Sprintf("parse error: %v", parseErr)
 			} else {
-				normalizeAction(&action)
+				normalizeAction(&action, r.reasonLimit(), r.tickSize)
 				r.repairAction(&action)
-				if validationErr := validateStrictAction(action); validationErr == "" {
+				if validationErr := validateStrictAction(action, r.settlement()); validationErr == "" {
 					return action, raw, nil
 				} else {
 					lastErr = validationErr
@@ -178,17 +617,30 @@ func (r *Runner) decideStrict(ctx context.Context, basePrompt llm.Prompt) (Actio
 		}
 
 		if attempt < decisionMaxAttempts {
-			prompt = strictRetryPrompt(basePrompt, lastErr, attempt)
+			prompt = strictRetryPrompt(basePrompt, lastErr, attempt, r.retryActions())
 		}
 	}
 
 	return Action{}, lastRaw, fmt.Errorf("failed to produce strict action after %d attempts: %s", decisionMaxAttempts, lastErr)
 }
 
-func validateStrictAction(action Action) string {
+// retryOptions halves the temperature on every attempt after the first,
+// dropping to 0 once it is negligible.
+func (r *Runner) retryOptions(attempt int) llm.Options {
+	if attempt <= 1 || r.Temperature <= 0 {
+		return llm.Options{}
+	}
+	temperature := r.Temperature / math.Pow(2, float64(attempt-1))
+	if temperature < minRetryTemperature {
+		temperature = 0
+	}
+	return llm.Options{Temperature: &temperature}
+}
+
+func validateStrictAction(action Action, settlement string) string {
 	act := strings.ToLower(strings.TrimSpace(action.Action))
 	switch act {
-	case "post_offer", "create_rfq", "trade", "wait":
+	case "post_offer", "create_rfq", "trade", "wait", "cancel", "respond_rfq", "mint":
 	default:
 		if act == "" {
 			return "missing action"
@@ -198,11 +650,26 @@ func validateStrictAction(action Action) string {
 		}
 		return fmt.Sprintf("invalid action: %s", action.Action)
 	}
+	if !isFinite(action.PriceAGC) {
+		return "price_agc must be a finite number"
+	}
+	if !isFinite(action.Qty) {
+		return "qty must be a finite number"
+	}
+	if action.Confidence != nil && !isFinite(*action.Confidence) {
+		return "confidence must be a finite number"
+	}
 
 	if act == "wait" {
 		if action.NextCheckSec < 0 {
 			return "next_check_sec must be >= 0"
 		}
+		return validateWatch(action.Watch, settlement)
+	}
+	if act == "cancel" {
+		if action.OfferID == "" && action.RFQID == "" && strings.TrimSpace(action.AssetSymbol) == "" {
+			return "cancel requires offer_id, rfq_id, or asset_symbol"
+		}
 		return ""
 	}
 
@@ -210,8 +677,8 @@ func validateStrictAction(action Action) string {
 	if asset == "" {
 		return "asset_symbol is required"
 	}
-	if asset == "AGC" {
-		return "asset_symbol must not be AGC"
+	if asset == settlement {
+		return "asset_symbol must not be " + settlement
 	}
 	if action.Qty <= 0 {
 		return "qty must be > 0"
@@ -223,20 +690,60 @@ func validateStrictAction(action Action) string {
 			return "trade side must be buy or sell"
 		}
 	}
-	if (act == "post_offer" || act == "create_rfq") && action.PriceAGC <= 0 {
+	if (act == "post_offer" || act == "create_rfq" || act == "respond_rfq") && action.PriceAGC <= 0 {
 		return "price_agc must be > 0"
 	}
+	if act == "respond_rfq" && action.RFQID == "" {
+		return "respond_rfq requires rfq_id"
+	}
 	return ""
 }
 
-func strictRetryPrompt(base llm.Prompt, reason string, attempt int) llm.Prompt {
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// retryHints are the per-action reminders strictRetryPrompt adds for the
+// actions it lists.
+var retryHints = map[string]string{
+	"wait":        "For wait, provide next_check_sec (1-60).",
+	"trade":       "For trade, include side.",
+	"cancel":      "For cancel, include offer_id or rfq_id.",
+	"respond_rfq": "For respond_rfq, include rfq_id and price_agc.",
+	"mint":        "For mint, include asset_symbol and qty.",
+}
+
+// retryActions lists the actions the main prompt offers: the permitted
+// executable ones (mint only while something is mintable) plus wait.
+func (r *Runner) retryActions() []string {
+	out := []string{}
+	for _, action := range r.permittedActions() {
+		if action == "mint" && r.mintNote() == "" {
+			continue
+		}
+		out = append(out, action)
+	}
+	return append(out, "wait")
+}
+
+func strictRetryPrompt(base llm.Prompt, reason string, attempt int, actions []string) llm.Prompt {
+	quoted := make([]string, 0, len(actions))
+	hints := []string{}
+	for _, action := range actions {
+		quoted = append(quoted, "'"+action+"'")
+		if hint, ok := retryHints[action]; ok {
+			hints = append(hints, hint)
+		}
+	}
+	hints = append(hints, "No noop, no markdown.")
 	addendum := fmt.Sprintf(
 		"\nPrevious output was rejected (%s). Attempt %d/%d. "+
-			"Return exactly one JSON object with action in ['post_offer','create_rfq','trade','wait']. "+
-			"For wait, provide next_check_sec (1-60). For trade, include side. No noop, no markdown.",
+			"Return exactly one JSON object with action in [%s]. %s",
 		strings.TrimSpace(reason),
 		attempt+1,
 		decisionMaxAttempts,
+		strings.Join(quoted, ","),
+		strings.Join(hints, " "),
 	)
 	return llm.Prompt{
 		System: base.System,
@@ -244,17 +751,49 @@ func strictRetryPrompt(base llm.Prompt, reason string, attempt int) llm.Prompt {
 	}
 }
 
-func normalizeWaitDuration(sec int) time.Duration {
+func (r *Runner) decisionCapReached() bool {
+	return r.MaxDecisions > 0 && r.decisionCount >= r.MaxDecisions
+}
+
+// belowConfidence reports whether an executable action falls under the
+// operator's MinConfidence; omitted confidence uses DefaultConfidence.
+func (r *Runner) belowConfidence(action Action) bool {
+	if r.MinConfidence <= 0 {
+		return false
+	}
+	confidence := r.DefaultConfidence
+	if action.Confidence != nil {
+		confidence = *action.Confidence
+	}
+	return confidence < r.MinConfidence
+}
+
+// waitDuration turns a next_check_sec into the delay before the next
+// decision: 0 means defaultWaitSec, and the result, after up to +/-5% random
+// jitter so a fleet does not wake in lockstep, stays within MinWait and
+// MaxWait (1s and 60s when unset).
+func (r *Runner) waitDuration(sec int) time.Duration {
 	if sec <= 0 {
 		sec = defaultWaitSec
 	}
-	if sec < minWaitSec {
-		sec = minWaitSec
+	lo, hi := r.waitBounds()
+	wait := max(lo, min(time.Duration(sec)*time.Second, hi))
+	if r.rng != nil {
+		wait += time.Duration(r.rng.Int63n(int64(wait)/10+1)) - wait/20
+	}
+	return max(lo, min(wait, hi))
+}
+
+// waitBounds is the effective [MinWait, MaxWait] range.
+func (r *Runner) waitBounds() (time.Duration, time.Duration) {
+	lo, hi := r.MinWait, r.MaxWait
+	if lo <= 0 {
+		lo = minWaitSec * time.Second
 	}
-	if sec > maxWaitSec {
-		sec = maxWaitSec
+	if hi <= 0 {
+		hi = maxWaitSec * time.Second
 	}
-	return time.Duration(sec) * time.Second
+	return lo, max(hi, lo)
 }
 
 func (r *Runner) repairAction(action *Action) {
@@ -262,7 +801,15 @@ func (r *Runner) repairAction(action *Action) {
 		return
 	}
 	act := strings.ToLower(strings.TrimSpace(action.Action))
-	if act == "" || act == "wait" || act == "noop" {
+	if act == "" || act == "wait" || act == "noop" || act == "cancel" {
+		return
+	}
+	if act == "respond_rfq" {
+		r.repairRespond(action)
+		return
+	}
+	if act == "mint" {
+		r.repairMint(action)
 		return
 	}
 
@@ -286,9 +833,9 @@ func (r *Runner) repairAction(action *Action) {
 	}
 
 	if (act == "post_offer" || act == "create_rfq" || act == "trade") && action.PriceAGC <= 0 {
-		price := r.lastTokenPrice[strings.ToUpper(strings.TrimSpace(action.AssetSymbol))]
-		if price > 0 {
-			action.PriceAGC = price
+		asset := strings.ToUpper(strings.TrimSpace(action.AssetSymbol))
+		if price := r.lastTokenPrice[asset]; price > 0 {
+			action.PriceAGC = roundToTick(price, r.tickSize(asset))
 		} else {
 			action.PriceAGC = 1
 		}
@@ -311,17 +858,18 @@ func (r *Runner) repairAction(action *Action) {
 }
 
 func (r *Runner) pickActionAsset(action string) string {
+	settlement := r.settlement()
 	allowed := map[string]struct{}{}
 	for _, symbol := range r.allowedTokens {
 		clean := strings.ToUpper(strings.TrimSpace(symbol))
-		if clean == "" || clean == "AGC" {
+		if clean == "" || clean == settlement {
 			continue
 		}
 		allowed[clean] = struct{}{}
 	}
 	accept := func(symbol string) bool {
 		clean := strings.ToUpper(strings.TrimSpace(symbol))
-		if clean == "" || clean == "AGC" {
+		if clean == "" || clean == settlement {
 			return false
 		}
 		if len(allowed) == 0 {
@@ -334,8 +882,9 @@ func (r *Runner) pickActionAsset(action string) string {
 	if action == "post_offer" || action == "trade" {
 		best := ""
 		bestQty := uint64(0)
-		for symbol, amount := range r.lastBalances {
+		for _, symbol := range sortedKeys(r.lastBalances) {
 			clean := strings.ToUpper(strings.TrimSpace(symbol))
+			amount := r.lastBalances[symbol]
 			if !accept(clean) || amount == 0 {
 				continue
 			}
@@ -349,28 +898,49 @@ func (r *Runner) pickActionAsset(action string) string {
 		}
 	}
 
-	for symbol := range r.lastTokenPrice {
+	for _, symbol := range sortedKeys(r.lastTokenPrice) {
 		clean := strings.ToUpper(strings.TrimSpace(symbol))
 		if accept(clean) {
 			return clean
 		}
 	}
-	for symbol := range allowed {
-		if symbol != "AGC" {
+	for _, symbol := range sortedKeys(allowed) {
+		if symbol != settlement {
 			return symbol
 		}
 	}
 	return ""
 }
 
+// sortedKeys keeps map-driven choices deterministic across runs.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (r *Runner) executeAction(ctx context.Context, action Action, raw string) {
+	if note := r.capOrderSize(&action); note != "" {
+		r.logf(logInfo, "%s %s: %s", action.Action, action.AssetSymbol, note)
+		action.Reason = strings.TrimSpace(action.Reason + " [" + note + "]")
+	}
+	if note := r.sizeRFQ(&action); note != "" {
+		r.logf(logInfo, "%s %s: %s", action.Action, action.AssetSymbol, note)
+		action.Reason = strings.TrimSpace(action.Reason + " [" + note + "]")
+	}
 	if status, errMsg := r.preflight(action); status != "" {
+		if errMsg != "asset in cooldown" {
+			r.startCooldown(action.AssetSymbol)
+		}
 		r.postDecision(ctx, action, status, errMsg, raw)
 		return
 	}
 	if r.Indexer == nil {
 		r.postDecision(ctx, action, "rejected", "no indexer configured", raw)
-		fmt.Println("no indexer configured for action execution")
+		r.logf(logError, "no indexer configured for action execution")
 		return
 	}
 
@@ -383,27 +953,51 @@ func (r *Runner) executeAction(ctx context.Context, action Action, raw string) {
 		Qty:         action.Qty,
 		Side:        strings.ToLower(strings.TrimSpace(action.Side)),
 		Reason:      strings.TrimSpace(action.Reason),
+		OfferID:     action.OfferID,
+		RFQID:       action.RFQID,
+	}
+	if req.Action == "post_offer" || req.Action == "create_rfq" {
+		req.TTLSec = r.orderTTL(action.TTLSec)
 	}
 
-	execCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	baseline, confirm := r.fillBaseline(ctx, req.Action)
+	execCtx, cancel := context.WithTimeout(ctx, r.Timeouts.PostAction)
 	err := r.Indexer.PostDevAction(execCtx, req)
 	cancel()
 	if err != nil {
+		r.startCooldown(req.AssetSymbol)
 		r.postDecision(ctx, action, "rejected", err.Error(), raw)
-		fmt.Printf("action failed: %v\n", err)
+		r.logf(logError, "action failed [%s]: %v", indexer.RequestID(ctx), err)
 		return
 	}
-	r.postDecision(ctx, action, "executed", "", raw)
-	fmt.Printf("action executed: %s %s\n", req.Action, req.AssetSymbol)
+	r.invalidateBalances()
+	r.consecutiveWaits = 0
+	status := "executed"
+	if confirm {
+		if fill, ok := r.awaitFill(ctx, baseline, req.AssetSymbol, req.Side, req.Qty); ok {
+			action.PriceAGC, action.Qty = fill.AvgPrice, fill.Qty
+			r.logf(logInfo, "fill confirmed [%s]: %s %.2f %s @ %.4f", indexer.RequestID(ctx), req.Side, fill.Qty, req.AssetSymbol, fill.AvgPrice)
+		} else {
+			status = "executed_unconfirmed"
+		}
+	}
+	r.postDecision(ctx, action, status, "", raw)
+	r.logf(logInfo, "action %s [%s]: %s %s", status, indexer.RequestID(ctx), req.Action, req.AssetSymbol)
 }
 
 func (r *Runner) buildPrompt(ctx context.Context) llm.Prompt {
 	system := "You are an autonomous market agent. Reply with a single JSON object only. " +
-		"Schema: {action: 'post_offer' | 'create_rfq' | 'trade' | 'wait', asset_symbol?: string, price_agc?: number, qty?: number, side?: 'buy' | 'sell', next_check_sec?: number, reason?: string}. " +
-		"Never return noop. If waiting, set action='wait' with next_check_sec (1-60)."
+		"Schema: {action: 'post_offer' | 'create_rfq' | 'trade' | 'cancel' | 'wait', asset_symbol?: string, offer_id?: string, rfq_id?: string, price_agc?: number, qty?: number, side?: 'buy' | 'sell', next_check_sec?: number, ttl_sec?: number (offer/RFQ expiry; shorter for aggressive quotes), reason?: string, confidence?: number (0-1), watch?: {asset_symbol: string, trigger: 'ask_below' | 'bid_above' | 'price_below' | 'price_above', price_agc: number}}. " +
+		"Never return noop. If waiting, set action='wait' with next_check_sec (1-60); add watch to be woken early when that price condition is met."
 	r.refreshAgentConfig(ctx)
-	if strings.TrimSpace(r.StrategyPrompt) != "" {
-		system += " Custom strategy instructions from user: " + strings.TrimSpace(r.StrategyPrompt)
+	if r.actionPermitted("respond_rfq") {
+		system += " You may also return action 'respond_rfq' with rfq_id to sell into a quote request addressed to you."
+	}
+	if r.mintNote() != "" {
+		system += " You may also return action 'mint' with asset_symbol and qty to mint a synthetic asset your policy allows."
+	}
+	if strategy := r.strategyPrompt(); strategy != "" {
+		system += " Custom strategy instructions from user: " + strategy
 	}
 
 	user := "No market snapshot available. Return {\"action\":\"wait\",\"next_check_sec\":5,\"reason\":\"market_unavailable\"}."
@@ -411,25 +1005,37 @@ func (r *Runner) buildPrompt(ctx context.Context) llm.Prompt {
 		return llm.Prompt{System: system, User: user}
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
 	defer cancel()
 
 	tokens, err := r.Indexer.GetTokens(ctx)
+	r.noteIndexerResult(err)
+	r.tokensLoaded = err == nil
 	if err != nil {
 		return llm.Prompt{System: system, User: user}
 	}
-	offers, _ := r.Indexer.GetOffers(ctx)
-	rfqs, _ := r.Indexer.GetRFQs(ctx)
+	r.applyAllowedTokens(tokens)
+	bookQuery := indexer.ListQuery{Status: "open", Assets: r.allowedTokens}
+	offers, _ := r.Indexer.GetOffers(ctx, bookQuery)
+	rfqs, _ := r.Indexer.GetRFQs(ctx, bookQuery)
+	offers, rfqs = r.filterCounterparties(offers, rfqs)
+	r.lastTokens = tokens
 	r.updateTokenPrices(tokens)
+	r.rescoreDecisionMemory()
 	r.lastOffers = offers
 	r.lastRFQs = rfqs
 
+	stale := r.stalePrices()
 	entries := make([]string, 0, 6)
 	for i, token := range tokens {
 		if i >= 6 {
 			break
 		}
-		entries = append(entries, fmt.Sprintf("%s %.2f (%+.2f%%)", token.Symbol, token.PriceAGC, token.Change24H))
+		entry := fmt.Sprintf("%s %.2f (%+.2f%%)", token.Symbol, token.PriceAGC, token.Change24H)
+		if _, ok := stale[strings.ToUpper(strings.TrimSpace(token.Symbol))]; ok {
+			entry += " stale price"
+		}
+		entries = append(entries, entry)
 	}
 
 	openOffers := 0
@@ -452,55 +1058,223 @@ func (r *Runner) buildPrompt(ctx context.Context) llm.Prompt {
 	r.lastOpenOffers = openOffers
 	r.lastOpenRFQs = openRFQs
 	r.lastOffersByAS = openByAsset
+	ownOrders := r.ownOrdersSummary()
 
 	holdings := r.formatHoldings()
 	profileGuide := profilePrompt(r.Profile)
-	allowedSummary := "any listed token except AGC"
+	settlement := r.settlement()
+	allowedSummary := "any listed token except " + settlement
 	if len(r.allowedTokens) > 0 {
 		allowedSummary = strings.Join(r.allowedTokens, ", ")
 	}
-	memorySummary := r.memorySummary()
 	learningSummary := r.memoryLessons()
-	opportunitySummary := summarizeOrderbook(tokens, offers, rfqs, r.AgentID, r.allowedTokens)
-	user = fmt.Sprintf(
-		"Agent %s (%s). Market snapshot: tokens [%s]. Offers: %d. RFQs: %d. Holdings: %s. "+
-			"You currently have %d open offers and %d open RFQs. Do not exceed 5 offers or 3 RFQs. "+
-			"Allowed asset symbols: [%s]. "+
-			"Never use AGC as asset_symbol; AGC is settlement only. "+
-			"Do not post offers for assets you don't own. If you only hold AGC, start with trade buy or RFQ. "+
-			"Orderbook lens: %s. "+
-			"Recent decision memory: %s. "+
-			"Learning hints: %s. "+
-			"You must decide one JSON action now: either execute (post_offer/create_rfq/trade) or wait with next_check_sec. %s Choose one action.",
-		r.AgentID, r.Profile, strings.Join(entries, ", "), len(offers), len(rfqs), holdings, openOffers, openRFQs, allowedSummary, opportunitySummary, memorySummary, learningSummary, profileGuide,
-	)
+	r.lastSignals = map[string]indexer.DecisionMetadata{}
+	opportunitySummary := summarizeOrderbook(tokens, offers, rfqs, r.AgentID, r.allowedTokens, settlement, r.MinVolume24H, r.lastSignals)
+	notes := []string{}
+	if strings.Contains(opportunitySummary, " thin") {
+		notes = append(notes, "Symbols marked thin have little 24h volume and possibly stale prices; prefer liquid ones")
+	}
+	if note := r.stalePriceNote(stale); note != "" {
+		notes = append(notes, note)
+	}
+	if len(r.AllowedMsgs) > 0 {
+		notes = append(notes, fmt.Sprintf("Policy permits only these actions (plus wait): [%s]", strings.Join(r.permittedActions(), ", ")))
+	}
+	if r.reduceOnly() {
+		notes = append(notes, "Reduce-only mode: only sell (trade sell or post_offer) up to what you already hold; buys and RFQs are blocked")
+	}
+	if r.PriceBandBps > 0 {
+		notes = append(notes, fmt.Sprintf("Prices must stay within %.2f%% of the current token price; anything further is blocked", r.PriceBandBps/100))
+	}
+	if demand := r.waitLimitNote(); demand != "" {
+		notes = append(notes, demand)
+	}
+	if r.watchNote != "" {
+		notes = append(notes, r.watchNote)
+		r.watchNote = ""
+	}
+	if sizing := r.sizingNote(); sizing != "" {
+		notes = append(notes, sizing)
+	}
+	if ticks := r.tickNote(); ticks != "" {
+		notes = append(notes, ticks)
+	}
+	if mint := r.mintNote(); mint != "" {
+		notes = append(notes, mint)
+	}
+	if quotes := r.quoteSummary(); quotes != "" {
+		notes = append(notes, "Quote at these target prices (offers at the ask, RFQs at the bid): "+quotes)
+	}
+	if r.MinAGCReserve > 0 {
+		notes = append(notes, fmt.Sprintf("Keep at least %d %s in reserve; only %d %s is spendable on trades, offers and RFQs", r.MinAGCReserve, settlement, r.spendableAGC(), settlement))
+	}
+	if r.hasProfile("arbitrage") {
+		if crosses := r.crossedBooks(); len(crosses) > 0 {
+			notes = append(notes, "Crossed books to arbitrage now: "+strings.Join(crosses, "; "))
+		}
+	}
+	if quotes := r.incomingRFQSummary(); quotes != "" {
+		notes = append(notes, "Quote requests addressed to you that you can answer with respond_rfq (rfq_id, asset_symbol, price_agc at or below max, qty up to the request and your holding): "+quotes)
+	}
+	if fills := r.fillSummary(); fills != "" {
+		notes = append(notes, "Fillable now against visible liquidity: "+fills)
+		if r.actionPermitted("create_rfq") && !r.reduceOnly() {
+			notes = append(notes, r.rfqFillNote())
+		}
+	}
+	if cooling := r.coolingAssets(); len(cooling) > 0 {
+		notes = append(notes, fmt.Sprintf("Assets in cooldown after recent rejections (do not act on them): [%s]", strings.Join(cooling, ", ")))
+	}
+	lensRows := strings.Split(opportunitySummary, "; ")
+	render := func(keep promptSections) string {
+		memorySummary := r.memorySummary(keep.memory)
+		opportunitySummary := strings.Join(lensRows[:min(keep.bookRows, len(lensRows))], "; ")
+		return fmt.Sprintf(
+			"Agent %s (%s). Market snapshot: tokens [%s]. Offers: %d. RFQs: %d. Holdings: %s. "+
+				"You currently have %d open offers and %d open RFQs (%s). Do not exceed 5 offers or 3 RFQs; cancel stale ones by id to free room. "+
+				"Allowed asset symbols: [%s]. "+
+				"Never use %s as asset_symbol; %s is settlement only (price_agc is quoted in %s). "+
+				"Do not post offers for assets you don't own. If you only hold %s, start with trade buy or RFQ. "+
+				"Orderbook lens: %s. "+
+				"Recent decision memory: %s. "+
+				"Learning hints: %s. "+
+				"%s"+
+				"You must decide one JSON action now: either execute (post_offer/create_rfq/trade/cancel) or wait with next_check_sec. %s Choose one action.",
+			r.AgentID, r.Profile, strings.Join(entries, ", "), len(offers), len(rfqs), holdings, openOffers, openRFQs, ownOrders, allowedSummary, settlement, settlement, settlement, settlement, opportunitySummary, memorySummary, learningSummary, joinPromptNotes(notes), profileGuide,
+		)
+	}
+	return r.fitPrompt(llm.Prompt{System: system}, render)
+}
 
-	return llm.Prompt{System: system, User: user}
+// ownOrdersSummary lists the agent's open orders with ids so the model can cancel them.
+func (r *Runner) ownOrdersSummary() string {
+	parts := []string{}
+	for _, offer := range r.lastOffers {
+		if offer.AgentID == r.AgentID && isOpenStatus(offer.Status) {
+			parts = append(parts, fmt.Sprintf("offer %s %s q=%.2f p=%.2f", offer.OfferID, strings.ToUpper(strings.TrimSpace(offer.Asset)), offer.Qty, offer.PriceAGC))
+		}
+	}
+	for _, rfq := range r.lastRFQs {
+		if rfq.AgentID == r.AgentID && isOpenStatus(rfq.Status) {
+			parts = append(parts, fmt.Sprintf("rfq %s %s q=%.2f max=%.2f", rfq.RFQID, strings.ToUpper(strings.TrimSpace(rfq.Asset)), rfq.Qty, rfq.MaxPriceAGC))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func joinPromptNotes(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+	return strings.Join(notes, ". ") + ". "
 }
 
-func parseAction(raw string) (Action, error) {
+// parseAction extracts the action object from raw model output. strict
+// rejects unknown fields instead of dropping them, and reports why an
+// object failed to decode rather than skipping it.
+func parseAction(raw string, strict bool) (Action, error) {
 	clean := strings.TrimSpace(raw)
 	if strings.HasPrefix(clean, "```") {
 		clean = strings.TrimPrefix(clean, "```")
 		clean = strings.TrimSuffix(clean, "```")
 		clean = strings.TrimSpace(clean)
 	}
-	if !strings.HasPrefix(clean, "{") {
-		start := strings.Index(clean, "{")
-		end := strings.LastIndex(clean, "}")
-		if start >= 0 && end > start {
-			clean = clean[start : end+1]
+	var fallback *Action
+	var strictErr error
+	for _, span := range jsonObjectSpans(clean) {
+		action, err := decodeAction(span, strict)
+		if err != nil {
+			if strictErr == nil {
+				strictErr = err
+			}
+			continue
+		}
+		if strings.TrimSpace(action.Action) != "" {
+			return action, nil
+		}
+		if fallback == nil {
+			fallback = &action
 		}
 	}
+	if fallback != nil {
+		return *fallback, nil
+	}
+	if strict && strictErr != nil {
+		return Action{}, strictErr
+	}
+	return decodeAction(clean, strict)
+}
+
+func decodeAction(data string, strict bool) (Action, error) {
 	var action Action
-	if err := json.Unmarshal([]byte(clean), &action); err != nil {
-		return Action{}, err
+	if !strict {
+		if err := json.Unmarshal([]byte(data), &action); err != nil {
+			return Action{}, err
+		}
+		return action, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&action); err != nil {
+		return Action{}, fmt.Errorf("%w; use only the schema's field names and types", err)
 	}
 	return action, nil
 }
 
-func resolveProfile(agentID, requested string) string {
+// jsonObjectSpans returns every top-level balanced {...} span in s, in order.
+// Braces inside JSON string literals are ignored so prose around (or between)
+// objects does not shift the boundaries.
+func jsonObjectSpans(s string) []string {
+	var spans []string
+	depth, start := 0, -1
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			if depth > 0 {
+				inString = true
+			}
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				spans = append(spans, s[start:i+1])
+			}
+		}
+	}
+	return spans
+}
+
+// ResolveProfile returns the requested profile (a blend is normalized, e.g.
+// "market_maker:0.60,momentum:0.40"), or when none is requested a stable pick
+// among market_maker, taker and momentum derived from agentID.
+func ResolveProfile(agentID, requested string) string {
 	requested = strings.ToLower(strings.TrimSpace(requested))
+	if weights, blend, err := parseProfileBlend(requested); blend && err == nil {
+		return canonicalBlend(weights)
+	}
 	if requested != "" {
 		return requested
 	}
@@ -520,6 +1294,9 @@ func resolveProfile(agentID, requested string) string {
 }
 
 func profilePrompt(profile string) string {
+	if weights, blend, err := parseProfileBlend(profile); blend && err == nil {
+		return blendPrompt(weights)
+	}
 	switch profile {
 	case "market_maker":
 		return "You are a market maker. Post tight offers near current price with small qty to earn spread."
@@ -527,13 +1304,19 @@ func profilePrompt(profile string) string {
 		return "You are a taker. Prefer trades or RFQs over posting many offers."
 	case "momentum":
 		return "You are momentum-biased. If change_24h is positive, prefer buy; if negative, prefer sell."
+	case "arbitrage":
+		return "You are an arbitrageur. Look for assets marked cross (bid >= ask) and trade immediately to capture the gap: buy at the ask, then sell to the bid. Otherwise wait."
 	default:
 		return "Be cautious and prefer small actions."
 	}
 }
 
 func (r *Runner) postDecision(ctx context.Context, action Action, status, errMsg, raw string) {
-	r.appendDecisionMemory(action, status, errMsg)
+	// Warm-up observations carry no outcome worth remembering.
+	if status != "observe" {
+		r.appendDecisionMemory(action, status, errMsg, indexer.RequestID(ctx))
+	}
+	r.countDecision(status)
 	if r.Indexer == nil {
 		return
 	}
@@ -545,13 +1328,22 @@ func (r *Runner) postDecision(ctx context.Context, action Action, status, errMsg
 		Qty:         action.Qty,
 		Side:        strings.ToLower(strings.TrimSpace(action.Side)),
 		Reason:      strings.TrimSpace(action.Reason),
-		Raw:         strings.TrimSpace(raw),
+		Raw:         truncateRaw(raw, r.rawLimit()),
 		Status:      status,
 		Error:       strings.TrimSpace(errMsg),
+		Metadata:    r.decisionMetadata(action.AssetSymbol),
 	}
-	execCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-	_ = r.Indexer.PostDevDecision(execCtx, req)
+	// Queue behind earlier failures so the indexer sees decisions in order.
+	if len(r.outbox) > 0 {
+		r.enqueue(outboxItem{Decision: &req, RequestID: indexer.RequestID(ctx)})
+		return
+	}
+	execCtx, cancel := context.WithTimeout(ctx, r.Timeouts.PostDecision)
+	err := r.Indexer.PostDevDecision(execCtx, req)
 	cancel()
+	if err != nil {
+		r.enqueue(outboxItem{Decision: &req, RequestID: indexer.RequestID(ctx)})
+	}
 }
 
 func (r *Runner) postHeartbeat(ctx context.Context) {
@@ -562,36 +1354,133 @@ func (r *Runner) postHeartbeat(ctx context.Context) {
 		AgentID:  strings.TrimSpace(r.AgentID),
 		Profile:  strings.TrimSpace(r.Profile),
 		UserAddr: strings.TrimSpace(r.UserAddr),
+		State:    r.state,
+	}
+	if r.state == stateWaiting || r.state == stateCooldown {
+		if !r.nextDecisionAt.IsZero() {
+			req.NextDecisionAt = r.nextDecisionAt.UTC().Format(time.RFC3339)
+		}
 	}
-	execCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-	_ = r.Indexer.PostDevHeartbeat(execCtx, req)
+	execCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Heartbeat)
+	err := r.Indexer.PostDevHeartbeat(execCtx, req)
 	cancel()
+	if err != nil {
+		r.enqueue(outboxItem{Heartbeat: &req})
+		return
+	}
+	r.dropQueuedHeartbeats()
+}
+
+// setState records what the runner is doing and announces the change with an
+// extra heartbeat, since deciding/executing rarely span a tick.
+func (r *Runner) setState(ctx context.Context, state string) {
+	if r.state == state {
+		return
+	}
+	r.state = state
+	r.postHeartbeat(ctx)
 }
 
 func (r *Runner) refreshAgentConfig(ctx context.Context) {
 	if r.Indexer == nil || strings.TrimSpace(r.AgentID) == "" {
 		return
 	}
-	if !r.lastAgentSync.IsZero() && time.Since(r.lastAgentSync) < 5*time.Second {
+	if !r.lastAgentSync.IsZero() && r.now().Sub(r.lastAgentSync) < 5*time.Second {
 		return
 	}
-	cfgCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	cfgCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Agent)
 	agentCfg, err := r.Indexer.GetAgent(cfgCtx, r.AgentID)
 	cancel()
-	r.lastAgentSync = time.Now()
+	r.lastAgentSync = r.now()
+	r.noteIndexerResult(err)
 	if err != nil {
 		return
 	}
-	r.StrategyPrompt = strings.TrimSpace(agentCfg.StrategyPrompt)
+	r.applyStrategyPrompt(agentCfg.StrategyPrompt, agentCfg.StrategyHash)
+	r.applyStrategyVersion(agentCfg.StrategyVersion)
 	nextAllowed := make([]string, 0, len(agentCfg.Policy.AllowedTokens))
 	for _, token := range agentCfg.Policy.AllowedTokens {
 		symbol := strings.ToUpper(strings.TrimSpace(token))
-		if symbol == "" || symbol == "AGC" {
+		if symbol == "" || symbol == r.settlement() {
 			continue
 		}
 		nextAllowed = append(nextAllowed, symbol)
 	}
-	r.allowedTokens = nextAllowed
+	r.policyAllowedTokens = nextAllowed
+	r.applyAllowedTokens(r.lastTokens)
+	r.policyAllowedCPs = agentCfg.Policy.AllowedCounterparties
+	r.policyDeniedCPs = agentCfg.Policy.DeniedCounterparties
+	r.policyReduceOnly = agentCfg.Policy.ReduceOnly
+	r.policyMintTokens = agentCfg.Policy.MintableTokens
+}
+
+// counterpartyAllowed applies the configured and policy counterparty lists.
+// The agent's own orders always pass; a non-empty allow list must contain
+// the agent, and any deny list entry excludes it.
+func (r *Runner) counterpartyAllowed(agentID string) bool {
+	agentID = strings.TrimSpace(agentID)
+	if agentID == r.AgentID {
+		return true
+	}
+	contains := func(list []string) bool {
+		for _, id := range list {
+			if strings.TrimSpace(id) == agentID {
+				return true
+			}
+		}
+		return false
+	}
+	if contains(r.DeniedCounterparties) || contains(r.policyDeniedCPs) {
+		return false
+	}
+	for _, allow := range [][]string{r.AllowedCounterparties, r.policyAllowedCPs} {
+		if len(allow) > 0 && !contains(allow) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterCounterparties drops orders from excluded counterparties so the
+// prompt, fill simulation and preflight only see tradable liquidity.
+func (r *Runner) filterCounterparties(offers []indexer.Offer, rfqs []indexer.RFQ) ([]indexer.Offer, []indexer.RFQ) {
+	keptOffers := offers[:0:0]
+	for _, offer := range offers {
+		if r.counterpartyAllowed(offer.AgentID) {
+			keptOffers = append(keptOffers, offer)
+		}
+	}
+	keptRFQs := rfqs[:0:0]
+	for _, rfq := range rfqs {
+		if r.counterpartyAllowed(rfq.AgentID) {
+			keptRFQs = append(keptRFQs, rfq)
+		}
+	}
+	return keptOffers, keptRFQs
+}
+
+// applyStrategyPrompt installs the registered strategy prompt only when it
+// matches the on-chain strategy hash. On mismatch the previous prompt is kept
+// and a warning is logged once per offending hash.
+func (r *Runner) applyStrategyPrompt(prompt, hash string) {
+	prompt = strings.TrimSpace(prompt)
+	expected := strings.ToLower(strings.TrimSpace(hash))
+	expected = strings.TrimPrefix(expected, "sha256:")
+	if expected == "" {
+		r.StrategyPrompt = prompt
+		return
+	}
+	sum := sha256.Sum256([]byte(prompt))
+	actual := hex.EncodeToString(sum[:])
+	if actual != expected {
+		if r.rejectedStrategyHash != expected {
+			r.logf(logWarn, "warning: strategy prompt hash mismatch (expected %s, got %s); keeping previous prompt", expected, actual)
+			r.rejectedStrategyHash = expected
+		}
+		return
+	}
+	r.rejectedStrategyHash = ""
+	r.StrategyPrompt = prompt
 }
 
 func (r *Runner) seedDecisionMemory(ctx context.Context) {
@@ -599,7 +1488,7 @@ func (r *Runner) seedDecisionMemory(ctx context.Context) {
 		return
 	}
 	r.memorySeeded = true
-	historyCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	historyCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Agent)
 	history, err := r.Indexer.GetAgentHistory(historyCtx, r.AgentID)
 	cancel()
 	if err != nil || len(history.Decisions) == 0 {
@@ -635,12 +1524,11 @@ func (r *Runner) seedDecisionMemory(ctx context.Context) {
 			Error:       strings.TrimSpace(item.Error),
 			Reason:      strings.TrimSpace(item.Reason),
 			CreatedAt:   strings.TrimSpace(item.CreatedAt),
-			Reward:      scoreDecisionOutcome(strings.TrimSpace(item.Status), strings.TrimSpace(item.Error)),
 		})
 	}
 }
 
-func (r *Runner) appendDecisionMemory(action Action, status, errMsg string) {
+func (r *Runner) appendDecisionMemory(action Action, status, errMsg, requestID string) {
 	r.pushDecisionMemory(memoryDecision{
 		Action:      strings.ToLower(strings.TrimSpace(action.Action)),
 		AssetSymbol: strings.ToUpper(strings.TrimSpace(action.AssetSymbol)),
@@ -650,11 +1538,22 @@ func (r *Runner) appendDecisionMemory(action Action, status, errMsg string) {
 		Status:      strings.ToLower(strings.TrimSpace(status)),
 		Error:       strings.TrimSpace(errMsg),
 		Reason:      strings.TrimSpace(action.Reason),
-		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
-		Reward:      scoreDecisionOutcome(status, errMsg),
+		CreatedAt:   r.now().UTC().Format(time.RFC3339),
+		RequestID:   requestID,
+		Market:      r.decisionMetadata(action.AssetSymbol),
 	})
 }
 
+// decisionMetadata is the orderbook signal and price context last seen for
+// asset, or nil when the lens had nothing on it.
+func (r *Runner) decisionMetadata(asset string) *indexer.DecisionMetadata {
+	meta, ok := r.lastSignals[strings.ToUpper(strings.TrimSpace(asset))]
+	if !ok {
+		return nil
+	}
+	return &meta
+}
+
 func (r *Runner) pushDecisionMemory(entry memoryDecision) {
 	if strings.TrimSpace(entry.Action) == "" {
 		return
@@ -663,21 +1562,28 @@ func (r *Runner) pushDecisionMemory(entry memoryDecision) {
 		entry.Status = "logged"
 	}
 	if strings.TrimSpace(entry.CreatedAt) == "" {
-		entry.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+		entry.CreatedAt = r.now().UTC().Format(time.RFC3339)
 	}
+	entry.Reward = r.decisionReward(entry)
 	r.decisionMemory = append(r.decisionMemory, entry)
 	if len(r.decisionMemory) > decisionMemoryLimit {
 		r.decisionMemory = r.decisionMemory[len(r.decisionMemory)-decisionMemoryLimit:]
 	}
 }
 
-func (r *Runner) memorySummary() string {
+// memorySummary describes the last keep decisions (at most
+// promptMemoryEntries).
+func (r *Runner) memorySummary(keep int) string {
 	if len(r.decisionMemory) == 0 {
 		return "none yet"
 	}
+	keep = min(keep, promptMemoryEntries)
+	if keep <= 0 {
+		return "omitted to fit the context window"
+	}
 	start := 0
-	if len(r.decisionMemory) > 6 {
-		start = len(r.decisionMemory) - 6
+	if len(r.decisionMemory) > keep {
+		start = len(r.decisionMemory) - keep
 	}
 	parts := make([]string, 0, len(r.decisionMemory)-start)
 	for _, item := range r.decisionMemory[start:] {
@@ -717,10 +1623,20 @@ func (r *Runner) memoryLessons() string {
 	liquidity := 0
 	schema := 0
 	limits := 0
+	winningTrades := 0
+	losingTrades := 0
 	for _, item := range r.decisionMemory {
+		if item.Action == "trade" && item.Status == "executed" {
+			pnl := tradePnLReward(item.Side, item.PriceAGC, r.lastTokenPrice[item.AssetSymbol])
+			if pnl > 0 {
+				winningTrades++
+			} else if pnl < 0 {
+				losingTrades++
+			}
+		}
 		status := strings.ToLower(strings.TrimSpace(item.Status))
 		switch status {
-		case "executed":
+		case "executed", "executed_unconfirmed":
 			executed++
 		case "wait":
 			waiting++
@@ -760,6 +1676,11 @@ func (r *Runner) memoryLessons() string {
 	if executed > 0 {
 		notes = append(notes, fmt.Sprintf("recently executed %d actions; reuse similar valid sizing", executed))
 	}
+	if losingTrades > winningTrades {
+		notes = append(notes, fmt.Sprintf("%d of %d recent trades are under water at current marks; demand better entry prices", losingTrades, losingTrades+winningTrades))
+	} else if winningTrades > 0 {
+		notes = append(notes, fmt.Sprintf("%d recent trades are profitable at current marks; favor similar entries", winningTrades))
+	}
 	if waiting > 0 && executed == 0 {
 		notes = append(notes, "waiting is acceptable, but seek a small executable trade when liquidity appears")
 	}
@@ -774,6 +1695,8 @@ func scoreDecisionOutcome(status, errMsg string) float64 {
 	switch strings.ToLower(strings.TrimSpace(status)) {
 	case "executed":
 		score = 0.8
+	case "executed_unconfirmed":
+		score = 0.4
 	case "wait":
 		score = 0.2
 	case "blocked":
@@ -800,17 +1723,96 @@ func scoreDecisionOutcome(status, errMsg string) float64 {
 	return score
 }
 
+// tradePnLReward scores an executed trade's price against the mark: buying
+// below or selling above the mark earns reward, the reverse costs it.
+func tradePnLReward(side string, price, mark float64) float64 {
+	if price <= 0 || mark <= 0 {
+		return 0
+	}
+	edge := (mark - price) / mark
+	if strings.ToLower(strings.TrimSpace(side)) == "sell" {
+		edge = -edge
+	}
+	return math.Max(-maxPnLReward, math.Min(maxPnLReward, edge*pnlRewardWeight))
+}
+
+func (r *Runner) decisionReward(entry memoryDecision) float64 {
+	score := scoreDecisionOutcome(entry.Status, entry.Error)
+	if entry.Action != "trade" || entry.Status != "executed" {
+		return score
+	}
+	return score + tradePnLReward(entry.Side, entry.PriceAGC, r.lastTokenPrice[entry.AssetSymbol])
+}
+
+// rescoreDecisionMemory re-marks executed trades against the latest token
+// prices so rewards track realized PnL rather than just execution success.
+func (r *Runner) rescoreDecisionMemory() {
+	for i := range r.decisionMemory {
+		r.decisionMemory[i].Reward = r.decisionReward(r.decisionMemory[i])
+	}
+}
+
 func (r *Runner) refreshBalances(ctx context.Context) {
 	if r.Indexer == nil || r.AgentID == "" {
 		return
 	}
-	balCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	if r.lastBalances != nil && r.BalancesTTL > 0 && r.now().Sub(r.lastBalancesAt) < r.BalancesTTL {
+		return
+	}
+	balCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
 	balances, err := r.Indexer.GetBalances(balCtx, r.AgentID)
 	cancel()
+	r.noteIndexerResult(err)
 	if err != nil {
 		return
 	}
 	r.lastBalances = balances
+	r.lastBalancesAt = r.now()
+}
+
+// invalidateBalances forces the next refreshBalances to hit the indexer.
+func (r *Runner) invalidateBalances() {
+	r.lastBalancesAt = time.Time{}
+}
+
+// noteIndexerResult tracks indexer reachability, logging only on transitions.
+func (r *Runner) noteIndexerResult(err error) {
+	if err == nil {
+		if r.indexerFailures > 0 {
+			r.logf(logInfo, "indexer reachable again; resuming normal cadence")
+		}
+		r.indexerFailures = 0
+		return
+	}
+	if r.indexerFailures == 0 {
+		r.logf(logWarn, "indexer unreachable (%v); backing off decisions", err)
+	}
+	r.indexerFailures++
+}
+
+// noLLMBackoff doubles the 5s retry delay per consecutive cycle without an
+// LLM client, up to maxIndexerBackoff.
+func noLLMBackoff(cycles int) time.Duration {
+	backoff := 5 * time.Second
+	for i := 1; i < cycles && backoff < maxIndexerBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxIndexerBackoff)
+}
+
+// indexerBackoff doubles the decision delay per consecutive indexer failure.
+func (r *Runner) indexerBackoff() time.Duration {
+	if r.indexerFailures == 0 {
+		return 0
+	}
+	backoff := r.Tick
+	for i := 1; i < r.indexerFailures && backoff < maxIndexerBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxIndexerBackoff {
+		backoff = maxIndexerBackoff
+	}
+	return backoff
 }
 
 func (r *Runner) updateTokenPrices(tokens []indexer.Token) {
@@ -834,26 +1836,34 @@ func (r *Runner) formatHoldings() string {
 	return strings.Join(entries, ", ")
 }
 
-func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []indexer.RFQ, selfAgent string, allowedTokens []string) string {
+// summarizeOrderbook ranks up to five symbols for the prompt, favoring
+// two-sided, liquid books. Symbols whose 24h volume is under minVolume (or,
+// when that is 0, under a tenth of the median listed volume) are marked thin
+// and ranked down. When signals is non-nil it receives every symbol's
+// signal and price context, not just the five shown.
+func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []indexer.RFQ, selfAgent string, allowedTokens []string, settlement string, minVolume float64, signals map[string]indexer.DecisionMetadata) string {
 	type marketRow struct {
 		symbol  string
 		last    float64
 		bestAsk float64
 		bestBid float64
+		volume  float64
+		thin    bool
 		score   int
 	}
 	allowed := map[string]struct{}{}
 	for _, token := range allowedTokens {
 		symbol := strings.ToUpper(strings.TrimSpace(token))
-		if symbol == "" || symbol == "AGC" {
+		if symbol == "" || symbol == settlement {
 			continue
 		}
 		allowed[symbol] = struct{}{}
 	}
 	tokenPrice := map[string]float64{}
+	tokenVolume := map[string]float64{}
 	for _, token := range tokens {
 		symbol := strings.ToUpper(strings.TrimSpace(token.Symbol))
-		if symbol == "" || symbol == "AGC" {
+		if symbol == "" || symbol == settlement {
 			continue
 		}
 		if len(allowed) > 0 {
@@ -862,6 +1872,10 @@ func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []i
 			}
 		}
 		tokenPrice[symbol] = token.PriceAGC
+		tokenVolume[symbol] = token.Volume24H
+	}
+	if minVolume <= 0 {
+		minVolume = medianVolume(tokenVolume) * thinVolumeFraction
 	}
 	bestAsk := map[string]float64{}
 	bestBid := map[string]float64{}
@@ -873,7 +1887,7 @@ func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []i
 			continue
 		}
 		symbol := strings.ToUpper(strings.TrimSpace(offer.Asset))
-		if symbol == "" || symbol == "AGC" {
+		if symbol == "" || symbol == settlement {
 			continue
 		}
 		if len(allowed) > 0 {
@@ -894,7 +1908,7 @@ func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []i
 			continue
 		}
 		symbol := strings.ToUpper(strings.TrimSpace(rfq.Asset))
-		if symbol == "" || symbol == "AGC" {
+		if symbol == "" || symbol == settlement {
 			continue
 		}
 		if len(allowed) > 0 {
@@ -927,8 +1941,18 @@ func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []i
 			last:    tokenPrice[symbol],
 			bestAsk: bestAsk[symbol],
 			bestBid: bestBid[symbol],
+			volume:  tokenVolume[symbol],
 			score:   0,
 		}
+		// Only listed tokens carry a volume; book-only symbols are not judged.
+		if _, listed := tokenVolume[symbol]; listed && minVolume > 0 {
+			row.thin = row.volume < minVolume
+			if row.thin {
+				row.score -= 2
+			} else {
+				row.score += 2
+			}
+		}
 		if row.bestAsk > 0 && row.bestBid > 0 {
 			row.score += 3
 			if row.bestBid >= row.bestAsk {
@@ -942,15 +1966,27 @@ func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []i
 			row.score++
 		}
 		rows = append(rows, row)
+		if signals != nil {
+			signals[symbol] = indexer.DecisionMetadata{
+				Signal:     bookSignal(row.last, row.bestBid, row.bestAsk),
+				Thin:       row.thin,
+				LastAGC:    row.last,
+				BestBidAGC: row.bestBid,
+				BestAskAGC: row.bestAsk,
+			}
+		}
 	}
 	sort.SliceStable(rows, func(i, j int) bool {
-		if rows[i].score == rows[j].score {
-			return rows[i].symbol < rows[j].symbol
+		if rows[i].score != rows[j].score {
+			return rows[i].score > rows[j].score
+		}
+		if rows[i].volume != rows[j].volume {
+			return rows[i].volume > rows[j].volume
 		}
-		return rows[i].score > rows[j].score
+		return rows[i].symbol < rows[j].symbol
 	})
-	if len(rows) > 5 {
-		rows = rows[:5]
+	if len(rows) > promptBookRows {
+		rows = rows[:promptBookRows]
 	}
 	parts := make([]string, 0, len(rows))
 	for _, row := range rows {
@@ -966,19 +2002,62 @@ func summarizeOrderbook(tokens []indexer.Token, offers []indexer.Offer, rfqs []i
 		if row.bestBid > 0 {
 			bidText = fmt.Sprintf("%.2f", row.bestBid)
 		}
-		signal := "watch"
-		if row.bestBid > 0 && row.bestAsk > 0 && row.bestBid >= row.bestAsk {
-			signal = "cross"
-		} else if row.bestBid > 0 && row.last > 0 && row.bestBid >= row.last {
-			signal = "strong_bid"
-		} else if row.bestAsk > 0 && row.last > 0 && row.bestAsk <= row.last {
-			signal = "cheap_ask"
+		signal := bookSignal(row.last, row.bestBid, row.bestAsk)
+		if row.thin {
+			signal += " thin"
+		}
+		bookText := ""
+		if mid, bps, ok := midSpread(row.bestBid, row.bestAsk); ok {
+			bookText = fmt.Sprintf(" mid=%.2f spread=%.0fbps", mid, bps)
 		}
-		parts = append(parts, fmt.Sprintf("%s last=%s bid=%s ask=%s %s", row.symbol, lastText, bidText, askText, signal))
+		parts = append(parts, fmt.Sprintf("%s last=%s bid=%s ask=%s%s vol=%.0f %s", row.symbol, lastText, bidText, askText, bookText, row.volume, signal))
 	}
 	return strings.Join(parts, "; ")
 }
 
+// bookSignal classifies a symbol's book against its last price: cross (bid
+// at or above ask), strong_bid, cheap_ask, or watch.
+func bookSignal(last, bid, ask float64) string {
+	switch {
+	case bid > 0 && ask > 0 && bid >= ask:
+		return "cross"
+	case bid > 0 && last > 0 && bid >= last:
+		return "strong_bid"
+	case ask > 0 && last > 0 && ask <= last:
+		return "cheap_ask"
+	}
+	return "watch"
+}
+
+// midSpread derives the book mid and the bid/ask spread in bps of the mid
+// (negative when crossed). ok is false unless both sides are quoted.
+func midSpread(bid, ask float64) (mid, bps float64, ok bool) {
+	if bid <= 0 || ask <= 0 {
+		return 0, 0, false
+	}
+	mid = (bid + ask) / 2
+	return mid, (ask - bid) / mid * 10000, true
+}
+
+// medianVolume is the median of the given 24h volumes (0 when empty).
+func medianVolume(volumes map[string]float64) float64 {
+	if len(volumes) == 0 {
+		return 0
+	}
+	sorted := make([]float64, 0, len(volumes))
+	for _, volume := range volumes {
+		sorted = append(sorted, volume)
+	}
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// trimForPrompt caps text at max bytes, ending in "..." when there is room,
+// and never splits a rune.
 func trimForPrompt(text string, max int) string {
 	trimmed := strings.TrimSpace(text)
 	if trimmed == "" || max <= 0 {
@@ -987,10 +2066,47 @@ func trimForPrompt(text string, max int) string {
 	if len(trimmed) <= max {
 		return trimmed
 	}
-	return trimmed[:max-3] + "..."
+	suffix := "..."
+	if max <= len(suffix) {
+		suffix = ""
+	}
+	cut := max - len(suffix)
+	for cut > 0 && !utf8.RuneStart(trimmed[cut]) {
+		cut--
+	}
+	return trimmed[:cut] + suffix
+}
+
+// truncateRaw keeps the head of raw and appends a marker recording how many
+// bytes were dropped.
+func truncateRaw(raw string, max int) string {
+	raw = strings.TrimSpace(raw)
+	if max <= 0 || len(raw) <= max {
+		return raw
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(raw[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", raw[:cut], len(raw)-cut)
+}
+
+func stripControlChars(text string) string {
+	return strings.Map(func(ch rune) rune {
+		if ch == '\n' || ch == '\r' || ch == '\t' {
+			return ' '
+		}
+		if unicode.IsControl(ch) {
+			return -1
+		}
+		return ch
+	}, text)
 }
 
 func (r *Runner) preflight(action Action) (string, string) {
+	if strings.ToLower(strings.TrimSpace(action.Action)) == "cancel" {
+		return r.preflightCancel(action)
+	}
 	if r.lastBalances == nil || len(r.lastBalances) == 0 {
 		return "blocked", "balances unavailable"
 	}
@@ -1002,8 +2118,29 @@ func (r *Runner) preflight(action Action) (string, string) {
 	if asset == "" {
 		return "blocked", "asset symbol missing"
 	}
-	if asset == "AGC" {
-		return "blocked", "AGC is settlement asset"
+	if asset == r.settlement() {
+		return "blocked", r.settlement() + " is settlement asset"
+	}
+	if !r.actionPermitted(action.Action) {
+		return "blocked", "action not permitted by policy"
+	}
+	if r.inCooldown(asset) {
+		return "blocked", "asset in cooldown"
+	}
+	if !r.withinPriceBand(asset, action.PriceAGC) {
+		return "blocked", "price outside band"
+	}
+	if status, errMsg := r.preflightStale(action, asset); status != "" {
+		return status, errMsg
+	}
+	if status, errMsg := r.preflightMinNotional(action, asset, qty); status != "" {
+		return status, errMsg
+	}
+	if status, errMsg := r.preflightReduceOnly(action, asset, qty); status != "" {
+		return status, errMsg
+	}
+	if status, errMsg := r.preflightSpread(action, asset); status != "" {
+		return status, errMsg
 	}
 
 	switch strings.ToLower(strings.TrimSpace(action.Action)) {
@@ -1017,14 +2154,17 @@ func (r *Runner) preflight(action Action) (string, string) {
 		if action.PriceAGC <= 0 {
 			return "blocked", "price must be positive"
 		}
+		if r.hasDuplicateOffer(asset, action.PriceAGC, action.Qty) {
+			return "blocked", "duplicate offer"
+		}
 		assetBal := r.lastBalances[asset]
 		mintQty := uint64(0)
 		if assetBal < qty {
 			mintQty = qty - assetBal
 		}
 		needAGC := offerFeeAGC + mintQty*syntheticMintFeePerUnitAGC
-		if r.lastBalances["AGC"] < needAGC {
-			return "blocked", "insufficient AGC for offer fee/mint"
+		if r.spendableAGC() < needAGC {
+			return "blocked", "insufficient " + r.settlement() + " for offer fee/mint"
 		}
 	case "create_rfq":
 		if r.lastOpenRFQs >= maxOpenRFQsPerAgent {
@@ -1038,9 +2178,16 @@ func (r *Runner) preflight(action Action) (string, string) {
 			return "blocked", "price unavailable"
 		}
 		cost := uint64(math.Round(price * float64(qty)))
-		if r.lastBalances["AGC"] < cost+rfqFeeAGC {
-			return "blocked", "insufficient AGC balance"
+		if r.spendableAGC() < cost+rfqFeeAGC {
+			return "blocked", "insufficient " + r.settlement() + " balance"
+		}
+		if status, errMsg := r.preflightRFQFill(asset, action.PriceAGC, qty); status != "" {
+			return status, errMsg
 		}
+	case "respond_rfq":
+		return r.preflightRespond(action, asset, qty)
+	case "mint":
+		return r.preflightMint(asset, qty)
 	case "trade":
 		side := strings.ToLower(strings.TrimSpace(action.Side))
 		if side != "buy" && side != "sell" {
@@ -1055,20 +2202,23 @@ func (r *Runner) preflight(action Action) (string, string) {
 		}
 		cost := uint64(math.Round(price * float64(qty)))
 		fee := calcTradeFee(cost)
+		if r.wouldSelfTrade(side, asset, price) {
+			return "blocked", "would self-trade"
+		}
 		if side == "sell" {
 			if r.lastBalances[asset] < qty {
 				return "blocked", "insufficient asset balance"
 			}
-			if r.lastBalances["AGC"] < fee {
-				return "blocked", "insufficient AGC for fee"
+			if r.spendableAGC() < fee {
+				return "blocked", "insufficient " + r.settlement() + " for fee"
 			}
 			if !r.hasTradeLiquidity(side, asset, price, qty) {
 				return "blocked", "no matching rfq liquidity"
 			}
 			return "", ""
 		}
-		if r.lastBalances["AGC"] < cost+fee {
-			return "blocked", "insufficient AGC balance"
+		if r.spendableAGC() < cost+fee {
+			return "blocked", "insufficient " + r.settlement() + " balance"
 		}
 		if !r.hasTradeLiquidity(side, asset, price, qty) {
 			return "blocked", "no matching offer liquidity"
@@ -1079,6 +2229,150 @@ func (r *Runner) preflight(action Action) (string, string) {
 	return "", ""
 }
 
+// withinPriceBand reports whether price is within PriceBandBps of the last
+// known mark. Unpriced actions and assets without a mark pass.
+func (r *Runner) withinPriceBand(asset string, price float64) bool {
+	mark := r.lastTokenPrice[asset]
+	if r.PriceBandBps <= 0 || price <= 0 || mark <= 0 {
+		return true
+	}
+	return math.Abs(price-mark)/mark*10000 <= r.PriceBandBps
+}
+
+func (r *Runner) reduceOnly() bool {
+	return r.ReduceOnly || r.policyReduceOnly
+}
+
+// preflightReduceOnly blocks anything that would grow a position: every buy
+// (trades and RFQs) and any sell or offer larger than the current holding.
+func (r *Runner) preflightReduceOnly(action Action, asset string, qty uint64) (string, string) {
+	if !r.reduceOnly() {
+		return "", ""
+	}
+	switch strings.ToLower(strings.TrimSpace(action.Action)) {
+	case "create_rfq":
+		return "blocked", "reduce-only: buys not allowed"
+	case "mint":
+		return "blocked", "reduce-only: minting not allowed"
+	case "trade":
+		if strings.ToLower(strings.TrimSpace(action.Side)) != "sell" {
+			return "blocked", "reduce-only: buys not allowed"
+		}
+	}
+	if qty > r.lastBalances[asset] {
+		return "blocked", "reduce-only: qty exceeds holding"
+	}
+	return "", ""
+}
+
+const defaultSettlementSymbol = "AGC"
+
+// settlement returns the settlement currency symbol.
+func (r *Runner) settlement() string {
+	if symbol := strings.ToUpper(strings.TrimSpace(r.SettlementSymbol)); symbol != "" {
+		return symbol
+	}
+	return defaultSettlementSymbol
+}
+
+// spendableAGC is the settlement balance left after holding back MinAGCReserve.
+func (r *Runner) spendableAGC() uint64 {
+	balance := r.lastBalances[r.settlement()]
+	if balance <= r.MinAGCReserve {
+		return 0
+	}
+	return balance - r.MinAGCReserve
+}
+
+// actionPermitted checks the action's message type against AllowedMsgs; an
+// empty policy permits everything except respond_rfq, which also needs
+// RespondRFQs.
+func (r *Runner) actionPermitted(action string) bool {
+	if strings.EqualFold(strings.TrimSpace(action), "respond_rfq") && !r.RespondRFQs {
+		return false
+	}
+	if len(r.AllowedMsgs) == 0 {
+		return true
+	}
+	// Cancelling only reduces exposure, so it follows the order-posting permissions.
+	if strings.EqualFold(strings.TrimSpace(action), "cancel") {
+		return r.actionPermitted("post_offer") || r.actionPermitted("create_rfq")
+	}
+	msg, ok := actionMsgTypes[strings.ToLower(strings.TrimSpace(action))]
+	if !ok {
+		return false
+	}
+	for _, allowed := range r.AllowedMsgs {
+		if strings.EqualFold(strings.TrimSpace(allowed), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) permittedActions() []string {
+	out := []string{}
+	for _, action := range sortedKeys(actionMsgTypes) {
+		if r.actionPermitted(action) {
+			out = append(out, action)
+		}
+	}
+	return out
+}
+
+func (r *Runner) startCooldown(asset string) {
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	if r.AssetCooldown <= 0 || asset == "" {
+		return
+	}
+	if r.cooldownUntil == nil {
+		r.cooldownUntil = map[string]time.Time{}
+	}
+	r.cooldownUntil[asset] = r.now().Add(r.AssetCooldown)
+}
+
+func (r *Runner) inCooldown(asset string) bool {
+	until, ok := r.cooldownUntil[strings.ToUpper(strings.TrimSpace(asset))]
+	return ok && r.now().Before(until)
+}
+
+func (r *Runner) coolingAssets() []string {
+	now := r.now()
+	out := make([]string, 0, len(r.cooldownUntil))
+	for asset, until := range r.cooldownUntil {
+		if now.Before(until) {
+			out = append(out, asset)
+			continue
+		}
+		delete(r.cooldownUntil, asset)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// preflightCancel only lets the agent cancel its own open orders.
+func (r *Runner) preflightCancel(action Action) (string, string) {
+	asset := strings.ToUpper(strings.TrimSpace(action.AssetSymbol))
+	byAsset := action.OfferID == "" && action.RFQID == ""
+	for _, offer := range r.lastOffers {
+		if offer.AgentID != r.AgentID || !isOpenStatus(offer.Status) {
+			continue
+		}
+		if (action.OfferID != "" && offer.OfferID == action.OfferID) || (byAsset && strings.ToUpper(strings.TrimSpace(offer.Asset)) == asset) {
+			return "", ""
+		}
+	}
+	for _, rfq := range r.lastRFQs {
+		if rfq.AgentID != r.AgentID || !isOpenStatus(rfq.Status) {
+			continue
+		}
+		if (action.RFQID != "" && rfq.RFQID == action.RFQID) || (byAsset && strings.ToUpper(strings.TrimSpace(rfq.Asset)) == asset) {
+			return "", ""
+		}
+	}
+	return "blocked", "no matching open order to cancel"
+}
+
 func calcTradeFee(notional uint64) uint64 {
 	if tradeFeeBps == 0 || notional == 0 {
 		return 0
@@ -1087,7 +2381,32 @@ func calcTradeFee(notional uint64) uint64 {
 	return fee
 }
 
-func normalizeAction(action *Action) {
+// orderTTL lets the model shorten, but never extend, the configured TTL.
+func (r *Runner) orderTTL(requested int) int {
+	if requested <= 0 {
+		return r.OrderTTLSec
+	}
+	if r.OrderTTLSec > 0 && requested > r.OrderTTLSec {
+		return r.OrderTTLSec
+	}
+	return requested
+}
+
+func (r *Runner) rawLimit() int {
+	if r.MaxRawChars > 0 {
+		return r.MaxRawChars
+	}
+	return decisionRawLimit
+}
+
+func (r *Runner) reasonLimit() int {
+	if r.MaxReasonChars > 0 {
+		return r.MaxReasonChars
+	}
+	return defaultReasonChars
+}
+
+func normalizeAction(action *Action, maxReason int, tickSize func(asset string) float64) {
 	if action == nil {
 		return
 	}
@@ -1110,6 +2429,12 @@ func normalizeAction(action *Action) {
 		clean = "trade"
 	case "wait", "hold", "observe", "pause":
 		clean = "wait"
+	case "cancel", "cancel_offer", "cancel_rfq", "cancel_order":
+		clean = "cancel"
+	case "respond_rfq", "quote", "respond_quote", "quote_rfq", "respond":
+		clean = "respond_rfq"
+	case "mint", "mint_asset", "mint_synthetic":
+		clean = "mint"
 	case "noop", "no_op":
 		clean = "noop"
 	}
@@ -1117,68 +2442,87 @@ func normalizeAction(action *Action) {
 	action.AssetSymbol = strings.ToUpper(strings.TrimSpace(action.AssetSymbol))
 	action.Side = strings.ToLower(strings.TrimSpace(action.Side))
 	action.Category = strings.TrimSpace(action.Category)
-	action.Reason = strings.TrimSpace(action.Reason)
+	action.Reason = trimForPrompt(stripControlChars(action.Reason), maxReason)
+	action.OfferID = strings.TrimSpace(action.OfferID)
+	action.RFQID = strings.TrimSpace(action.RFQID)
 	if action.NextCheckSec < 0 {
 		action.NextCheckSec = 0
 	}
+	if action.TTLSec < 0 {
+		action.TTLSec = 0
+	}
+	if tickSize != nil {
+		action.PriceAGC = roundToTick(action.PriceAGC, tickSize(action.AssetSymbol))
+	}
+	normalizeWatch(action.Watch)
+	// Non-finite confidence is left for validateStrictAction to reject.
+	if action.Confidence != nil && isFinite(*action.Confidence) {
+		confidence := math.Max(0, math.Min(1, *action.Confidence))
+		action.Confidence = &confidence
+	}
 	if action.AssetSymbol == "" {
 		return
 	}
 }
 
-func (r *Runner) hasTradeLiquidity(side, asset string, price float64, qty uint64) bool {
-	if qty == 0 {
-		return false
-	}
-	asset = strings.ToUpper(strings.TrimSpace(asset))
-	side = strings.ToLower(strings.TrimSpace(side))
-	if asset == "" || (side != "buy" && side != "sell") {
-		return false
+// hasDuplicateOffer reports whether the agent already has an open offer for
+// asset at the same price and qty.
+func (r *Runner) hasDuplicateOffer(asset string, price, qty float64) bool {
+	const eps = 1e-6
+	for _, offer := range r.lastOffers {
+		if offer.AgentID != r.AgentID || !isOpenStatus(offer.Status) {
+			continue
+		}
+		if strings.ToUpper(strings.TrimSpace(offer.Asset)) != asset {
+			continue
+		}
+		if math.Abs(offer.PriceAGC-price) <= eps && math.Abs(offer.Qty-math.Round(qty)) <= eps {
+			return true
+		}
 	}
-	remaining := float64(qty)
+	return false
+}
+
+// wouldSelfTrade reports whether a trade at price would cross one of the
+// agent's own resting offers (buy) or RFQs (sell) on the same asset.
+func (r *Runner) wouldSelfTrade(side, asset string, price float64) bool {
 	const eps = 1e-9
+	asset = strings.ToUpper(strings.TrimSpace(asset))
 	if side == "buy" {
 		for _, offer := range r.lastOffers {
-			if offer.AgentID == r.AgentID {
+			if offer.AgentID != r.AgentID || !isOpenStatus(offer.Status) || offer.Qty <= 0 {
 				continue
 			}
-			if !isOpenStatus(offer.Status) {
-				continue
-			}
-			if strings.ToUpper(strings.TrimSpace(offer.Asset)) != asset {
-				continue
-			}
-			if offer.PriceAGC > price+eps {
-				continue
-			}
-			remaining -= offer.Qty
-			if remaining <= eps {
+			if strings.ToUpper(strings.TrimSpace(offer.Asset)) == asset && offer.PriceAGC <= price+eps {
 				return true
 			}
 		}
 		return false
 	}
 	for _, rfq := range r.lastRFQs {
-		if rfq.AgentID == r.AgentID {
-			continue
-		}
-		if !isOpenStatus(rfq.Status) {
-			continue
-		}
-		if strings.ToUpper(strings.TrimSpace(rfq.Asset)) != asset {
-			continue
-		}
-		if rfq.MaxPriceAGC+eps < price {
+		if rfq.AgentID != r.AgentID || !isOpenStatus(rfq.Status) || rfq.Qty <= 0 {
 			continue
 		}
-		remaining -= rfq.Qty
-		if remaining <= eps {
+		if strings.ToUpper(strings.TrimSpace(rfq.Asset)) == asset && rfq.MaxPriceAGC+eps >= price {
 			return true
 		}
 	}
 	return false
 }
 
+func (r *Runner) hasTradeLiquidity(side, asset string, price float64, qty uint64) bool {
+	if qty == 0 {
+		return false
+	}
+	asset = strings.ToUpper(strings.TrimSpace(asset))
+	side = strings.ToLower(strings.TrimSpace(side))
+	if asset == "" || (side != "buy" && side != "sell") {
+		return false
+	}
+	const eps = 1e-9
+	return r.simulateFill(side, asset, price, float64(qty)).Qty >= float64(qty)-eps
+}
+
 func isOpenStatus(status string) bool {
 	status = strings.ToLower(strings.TrimSpace(status))
 	return status == "" || status == "open"
@@ -0,0 +1,158 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"agentmarket/agent/internal/indexer"
+	"agentmarket/agent/internal/indexer/indexertest"
+	"agentmarket/agent/internal/llm"
+)
+
+const testAgentID = "agent-1"
+
+// newTestRunner returns a runner for testAgentID on a stub market with one
+// token, FOO at 2.30, offered by another agent, replaying responses from the
+// mock LLM in a cycle.
+func newTestRunner(t *testing.T, responses ...string) (*Runner, *indexertest.Indexer) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mock.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(responses, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := llm.New(llm.Config{Provider: "mock", MockFile: path, MockCycle: true})
+	if err != nil {
+		t.Fatalf("mock llm: %v", err)
+	}
+	stub := indexertest.New()
+	stub.SetAgent(indexer.Agent{AgentID: testAgentID, UserAddr: "user-1", Status: "active"})
+	stub.SetTokens(indexer.Token{Symbol: "FOO", PriceAGC: 2.3, Volume24H: 100})
+	stub.SetOffers(indexer.Offer{OfferID: "offer-9", AgentID: "agent-9", Asset: "FOO", PriceAGC: 2.3, Qty: 10, Status: "open"})
+	stub.SetBalances(testAgentID, map[string]uint64{"AGC": 1000, "FOO": 10})
+	r := NewRunner(testAgentID, client, nil)
+	r.Indexer = stub
+	r.NoStartJitter = true
+	r.LogLevel = "error"
+	return r, stub
+}
+
+func TestLowConfidenceTradeBecomesWait(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		confidence string
+		wantAction string
+		wantStatus string
+		executed   int
+	}{
+		{name: "below minimum", confidence: "0.2", wantAction: "wait", wantStatus: "wait", executed: 0},
+		{name: "at minimum", confidence: "0.5", wantAction: "trade", wantStatus: "executed", executed: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, stub := newTestRunner(t, `{"action":"trade","side":"buy","asset_symbol":"FOO","qty":1,"price_agc":2.3,"confidence":`+tc.confidence+`,"reason":"test"}`)
+			r.MinConfidence = 0.5
+			r.decide(context.Background())
+
+			if got := len(stub.Actions()); got != tc.executed {
+				t.Fatalf("executed %d actions, want %d", got, tc.executed)
+			}
+			decisions := stub.Decisions()
+			if len(decisions) != 1 {
+				t.Fatalf("got %d decisions, want 1", len(decisions))
+			}
+			d := decisions[0]
+			if d.Action != tc.wantAction || d.Status != tc.wantStatus {
+				t.Fatalf("decision %s/%s, want %s/%s", d.Action, d.Status, tc.wantAction, tc.wantStatus)
+			}
+			if tc.wantAction == "wait" && d.Reason != "low_confidence" {
+				t.Fatalf("reason %q, want low_confidence", d.Reason)
+			}
+		})
+	}
+}
+
+// countingLLM counts the generate calls that reach the wrapped client.
+type countingLLM struct {
+	llm.Client
+	calls atomic.Int64
+}
+
+func (c *countingLLM) Generate(ctx context.Context, prompt llm.Prompt) (string, error) {
+	c.calls.Add(1)
+	return c.Client.Generate(ctx, prompt)
+}
+
+func (c *countingLLM) GenerateWithOptions(ctx context.Context, prompt llm.Prompt, opts llm.Options) (string, error) {
+	c.calls.Add(1)
+	return c.Client.GenerateWithOptions(ctx, prompt, opts)
+}
+
+func TestMaxDecisionsStopsLLMCalls(t *testing.T) {
+	for _, exit := range []bool{false, true} {
+		t.Run(fmt.Sprintf("exit=%t", exit), func(t *testing.T) {
+			r, _ := newTestRunner(t, `{"action":"wait","next_check_sec":1,"reason":"test"}`)
+			client := &countingLLM{Client: r.LLM}
+			r.LLM = client
+			r.MaxDecisions = 3
+			r.ExitOnMaxDecisions = exit
+
+			for i := 0; i < 6; i++ {
+				stop := r.decide(context.Background())
+				if want := exit && i >= r.MaxDecisions; stop != want {
+					t.Fatalf("decide %d reported stop=%t, want %t", i+1, stop, want)
+				}
+			}
+			if got := client.calls.Load(); got != int64(r.MaxDecisions) {
+				t.Fatalf("llm called %d times, want %d", got, r.MaxDecisions)
+			}
+		})
+	}
+}
+
+func TestTrimForPrompt(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		max  int
+		want string
+	}{
+		{text: "short", max: 10, want: "short"},
+		{text: "a longer reason", max: 8, want: "a lon..."},
+		{text: "reason", max: 1, want: "r"},
+		{text: "reason", max: 3, want: "rea"},
+		{text: "prix élevé", max: 9, want: "prix ..."},
+		{text: "élevé", max: 2, want: "é"},
+		{text: "élevé", max: 1, want: ""},
+	} {
+		got := trimForPrompt(tc.text, tc.max)
+		if got != tc.want {
+			t.Fatalf("trimForPrompt(%q, %d) = %q, want %q", tc.text, tc.max, got, tc.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Fatalf("trimForPrompt(%q, %d) split a rune: %q", tc.text, tc.max, got)
+		}
+	}
+}
+
+func TestVerboseOverridesLogLevel(t *testing.T) {
+	for _, tc := range []struct {
+		level   string
+		verbose bool
+		want    logLevel
+	}{
+		{level: "", verbose: false, want: logInfo},
+		{level: "warn", verbose: false, want: logWarn},
+		{level: "", verbose: true, want: logDebug},
+		{level: "info", verbose: true, want: logDebug},
+		{level: "error", verbose: true, want: logDebug},
+	} {
+		r := &Runner{LogLevel: tc.level, Verbose: tc.verbose}
+		if got := r.logLevel(); got != tc.want {
+			t.Fatalf("log level %q verbose=%t resolved to %d, want %d", tc.level, tc.verbose, got, tc.want)
+		}
+	}
+}
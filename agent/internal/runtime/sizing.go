@@ -0,0 +1,95 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// equity is the account value in the settlement currency: its balance plus
+// every holding marked at its last token price. Unpriced holdings count as 0.
+func (r *Runner) equity() float64 {
+	total := 0.0
+	for denom, amount := range r.lastBalances {
+		if denom == r.settlement() {
+			total += float64(amount)
+			continue
+		}
+		total += float64(amount) * r.lastTokenPrice[denom]
+	}
+	return total
+}
+
+// maxOrderNotional is the largest order value allowed by
+// MaxOrderEquityFraction (0 = uncapped or equity unknown).
+func (r *Runner) maxOrderNotional() float64 {
+	if r.MaxOrderEquityFraction <= 0 {
+		return 0
+	}
+	return r.equity() * r.MaxOrderEquityFraction
+}
+
+// capOrderSize shrinks the qty of a trade, offer or RFQ so its notional fits
+// within maxOrderNotional and returns a note describing the adjustment ("" if
+// none). Orders that cannot fit even one unit are left for preflight to judge.
+func (r *Runner) capOrderSize(action *Action) string {
+	switch strings.ToLower(strings.TrimSpace(action.Action)) {
+	case "trade", "post_offer", "create_rfq", "respond_rfq":
+	default:
+		return ""
+	}
+	limit := r.maxOrderNotional()
+	if limit <= 0 {
+		return ""
+	}
+	asset := strings.ToUpper(strings.TrimSpace(action.AssetSymbol))
+	price := action.PriceAGC
+	if price <= 0 {
+		price = r.lastTokenPrice[asset]
+	}
+	if price <= 0 || price*math.Round(action.Qty) <= limit {
+		return ""
+	}
+	maxQty := math.Floor(limit / price)
+	if maxQty < 1 {
+		return ""
+	}
+	action.Qty = maxQty
+	return fmt.Sprintf("size capped to %.0f", maxQty)
+}
+
+// preflightMinNotional blocks trades, offers and RFQs whose notional is
+// under MinNotionalAGC, pricing unpriced ones at the last token price as
+// preflight does. Orders with no known price are left to preflight.
+func (r *Runner) preflightMinNotional(action Action, asset string, qty uint64) (string, string) {
+	if r.MinNotionalAGC <= 0 {
+		return "", ""
+	}
+	switch strings.ToLower(strings.TrimSpace(action.Action)) {
+	case "trade", "post_offer", "create_rfq", "respond_rfq":
+	default:
+		return "", ""
+	}
+	price := action.PriceAGC
+	if price <= 0 {
+		price = r.lastTokenPrice[asset]
+	}
+	if price <= 0 || price*float64(qty) >= r.MinNotionalAGC {
+		return "", ""
+	}
+	return "blocked", fmt.Sprintf("below minimum notional (%.4f < %.4f %s)", price*float64(qty), r.MinNotionalAGC, r.settlement())
+}
+
+// sizingNote tells the model the current equity-based order cap.
+func (r *Runner) sizingNote() string {
+	notes := []string{}
+	if limit := r.maxOrderNotional(); limit > 0 {
+		notes = append(notes, fmt.Sprintf("Keep each order's notional (price x qty) under %.2f %s (%.0f%% of equity %.2f %s); larger sizes are cut down",
+			limit, r.settlement(), r.MaxOrderEquityFraction*100, r.equity(), r.settlement()))
+	}
+	if r.MinNotionalAGC > 0 {
+		notes = append(notes, fmt.Sprintf("Orders under %.2f %s notional (price x qty) are blocked as dust whose fees outweigh their value",
+			r.MinNotionalAGC, r.settlement()))
+	}
+	return strings.Join(notes, ". ")
+}
@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// spreadBps is the market maker's target bid/ask spread for asset: the
+// per-asset setting if any, else SpreadBps. 0 leaves pricing to the model.
+func (r *Runner) spreadBps(asset string) float64 {
+	if !r.hasProfile("market_maker") {
+		return 0
+	}
+	if bps, ok := r.AssetSpreadBps[strings.ToUpper(strings.TrimSpace(asset))]; ok {
+		return bps
+	}
+	return r.SpreadBps
+}
+
+// quote returns bid and ask prices spaced spreadBps around the last token
+// price, or ok=false when no spread is configured or the price is unknown.
+func (r *Runner) quote(asset string) (bid, ask float64, ok bool) {
+	bps := r.spreadBps(asset)
+	mark := r.lastTokenPrice[asset]
+	if bps <= 0 || mark <= 0 {
+		return 0, 0, false
+	}
+	half := mark * bps / 20000
+	tick := r.tickSize(asset)
+	return roundToTick(mark-half, tick), roundToTick(mark+half, tick), true
+}
+
+// quoteSummary lists suggested quotes for every tradable asset with a spread.
+func (r *Runner) quoteSummary() string {
+	assets := r.allowedTokens
+	if len(assets) == 0 {
+		assets = sortedKeys(r.lastTokenPrice)
+	}
+	parts := []string{}
+	for _, asset := range assets {
+		bid, ask, ok := r.quote(asset)
+		if !ok {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s bid %.4f / ask %.4f (%.0f bps)", asset, bid, ask, r.spreadBps(asset)))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// preflightSpread blocks market maker quotes on the wrong side of the mark:
+// offers (asks) priced below it and RFQs (bids) priced above it.
+func (r *Runner) preflightSpread(action Action, asset string) (string, string) {
+	bid, ask, ok := r.quote(asset)
+	if !ok || action.PriceAGC <= 0 {
+		return "", ""
+	}
+	mark := r.lastTokenPrice[asset]
+	switch strings.ToLower(strings.TrimSpace(action.Action)) {
+	case "post_offer":
+		if action.PriceAGC < mark {
+			return "blocked", fmt.Sprintf("offer below mark; target ask %.4f", ask)
+		}
+	case "create_rfq":
+		if action.PriceAGC > mark {
+			return "blocked", fmt.Sprintf("rfq above mark; target bid %.4f", bid)
+		}
+	}
+	return "", ""
+}
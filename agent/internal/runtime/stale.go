@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// lastTradeAge is how long ago token last traded. ok is false when
+// LastTradeAt is empty or not RFC3339; such marks are never counted stale.
+func lastTradeAge(token indexer.Token, now time.Time) (time.Duration, bool) {
+	raw := strings.TrimSpace(token.LastTradeAt)
+	if raw == "" {
+		return 0, false
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return 0, false
+	}
+	return max(0, now.Sub(at)), true
+}
+
+// stalePrices maps each listed symbol whose last trade is older than
+// StalePriceAfter to the age of its mark.
+func (r *Runner) stalePrices() map[string]time.Duration {
+	stale := map[string]time.Duration{}
+	if r.StalePriceAfter <= 0 {
+		return stale
+	}
+	now := r.now()
+	for _, token := range r.lastTokens {
+		if age, ok := lastTradeAge(token, now); ok && age > r.StalePriceAfter {
+			stale[strings.ToUpper(strings.TrimSpace(token.Symbol))] = age
+		}
+	}
+	return stale
+}
+
+// stalePriceNote tells the model which marks are outdated.
+func (r *Runner) stalePriceNote(stale map[string]time.Duration) string {
+	if len(stale) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(stale))
+	for _, symbol := range sortedKeys(stale) {
+		parts = append(parts, fmt.Sprintf("%s (last trade %s ago)", symbol, stale[symbol].Round(time.Minute)))
+	}
+	note := "Stale price, no trade for over " + r.StalePriceAfter.String() + ": " + strings.Join(parts, ", ") + "; treat these marks as outdated"
+	if r.BlockStalePrices {
+		note += " (orders on them are blocked)"
+	}
+	return note
+}
+
+// preflightStale blocks orders on an asset whose mark is stale when
+// BlockStalePrices is set.
+func (r *Runner) preflightStale(action Action, asset string) (string, string) {
+	if !r.BlockStalePrices {
+		return "", ""
+	}
+	switch strings.ToLower(strings.TrimSpace(action.Action)) {
+	case "trade", "post_offer", "create_rfq", "respond_rfq":
+	default:
+		return "", ""
+	}
+	if age, ok := r.stalePrices()[asset]; ok {
+		return "blocked", fmt.Sprintf("stale price: no trade for %s", age.Round(time.Minute))
+	}
+	return "", ""
+}
@@ -0,0 +1,39 @@
+package runtime
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// localStrategyPrompt returns the contents of StrategyPromptFile, re-reading
+// it whenever its modification time changes so edits apply on the next
+// cycle. A missing or unreadable file yields "" and is logged once per error.
+func (r *Runner) localStrategyPrompt() string {
+	path := strings.TrimSpace(r.StrategyPromptFile)
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err == nil && info.ModTime().Equal(r.localPromptMod) {
+		return r.localPrompt
+	}
+	var data []byte
+	if err == nil {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		if msg := err.Error(); msg != r.localPromptErr {
+			r.logf(logWarn, "strategy prompt file unusable, using the registered prompt: %v", err)
+			r.localPromptErr = msg
+		}
+		r.localPrompt = ""
+		r.localPromptMod = time.Time{}
+		return ""
+	}
+	r.localPromptErr = ""
+	r.localPromptMod = info.ModTime()
+	r.localPrompt = strings.TrimSpace(string(data))
+	r.logf(logInfo, "strategy prompt: loaded %s (%d chars); it overrides the registered prompt", path, len(r.localPrompt))
+	return r.localPrompt
+}
@@ -0,0 +1,62 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The strategy schema major versions this runtime understands.
+const (
+	minStrategyVersion = 1
+	maxStrategyVersion = 1
+)
+
+// SupportedStrategyVersions describes the accepted range for messages.
+func SupportedStrategyVersions() string {
+	if minStrategyVersion == maxStrategyVersion {
+		return fmt.Sprintf("%d.x", minStrategyVersion)
+	}
+	return fmt.Sprintf("%d.x-%d.x", minStrategyVersion, maxStrategyVersion)
+}
+
+// StrategyVersionSupported reports whether version ("1", "1.2", "v1.0.3")
+// has a major version in the supported range. An empty version predates
+// versioning and is accepted; one that does not parse is not.
+func StrategyVersionSupported(version string) bool {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	if version == "" {
+		return true
+	}
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return false
+	}
+	return n >= minStrategyVersion && n <= maxStrategyVersion
+}
+
+// applyStrategyVersion records the registered strategy version and warns
+// once per version this runtime does not support.
+func (r *Runner) applyStrategyVersion(version string) {
+	version = strings.TrimSpace(version)
+	r.strategyVersion = version
+	if StrategyVersionSupported(version) {
+		r.warnedVersion = ""
+		return
+	}
+	if r.warnedVersion != version {
+		action := "continuing anyway"
+		if r.StrictStrategyVersion {
+			action = "holding all actions"
+		}
+		r.logf(logWarn, "warning: strategy version %s is outside the supported range %s; %s (upgrade agentd)", version, SupportedStrategyVersions(), action)
+		r.warnedVersion = version
+	}
+}
+
+// strategyVersionBlocked reports whether StrictStrategyVersion is holding
+// the agent because of an unsupported strategy version.
+func (r *Runner) strategyVersionBlocked() bool {
+	return r.StrictStrategyVersion && !StrategyVersionSupported(r.strategyVersion)
+}
@@ -0,0 +1,67 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// tickSize is the price increment for asset: the per-asset setting if any,
+// else TickSize. 0 leaves prices unrounded.
+func (r *Runner) tickSize(asset string) float64 {
+	if tick, ok := r.AssetTickSize[strings.ToUpper(strings.TrimSpace(asset))]; ok {
+		return tick
+	}
+	return r.TickSize
+}
+
+// roundToTick rounds price to the nearest multiple of tick. A positive price
+// never rounds to zero: anything under one tick becomes one tick, so priced
+// actions stay valid. Non-positive prices and ticks are returned unchanged.
+func roundToTick(price, tick float64) float64 {
+	if tick <= 0 || price <= 0 || !isFinite(price) {
+		return price
+	}
+	rounded := math.Max(1, math.Round(price/tick)) * tick
+	// Drop float noise such as 2.3200000000000003 at the tick's precision.
+	if decimals := tickDecimals(tick); decimals >= 0 {
+		scale := math.Pow(10, float64(decimals))
+		rounded = math.Round(rounded*scale) / scale
+	}
+	return rounded
+}
+
+// tickDecimals is the number of decimal places in tick, or -1 when it has
+// more than float64 can round to reliably.
+func tickDecimals(tick float64) int {
+	s := strconv.FormatFloat(tick, 'f', -1, 64)
+	i := strings.IndexByte(s, '.')
+	if i < 0 {
+		return 0
+	}
+	if decimals := len(s) - i - 1; decimals <= 12 {
+		return decimals
+	}
+	return -1
+}
+
+// tickNote tells the model which price increments its prices are rounded to.
+func (r *Runner) tickNote() string {
+	if r.TickSize <= 0 && len(r.AssetTickSize) == 0 {
+		return ""
+	}
+	parts := []string{}
+	if r.TickSize > 0 {
+		parts = append(parts, fmt.Sprintf("%g %s", r.TickSize, r.settlement()))
+	}
+	for _, asset := range sortedKeys(r.AssetTickSize) {
+		if tick := r.AssetTickSize[asset]; tick > 0 {
+			parts = append(parts, fmt.Sprintf("%s %g", asset, tick))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Prices are rounded to the nearest tick: " + strings.Join(parts, ", ")
+}
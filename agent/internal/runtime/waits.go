@@ -0,0 +1,91 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// waitLimitReached reports whether the model has waited MaxConsecutiveWaits
+// cycles in a row (0 = no limit).
+func (r *Runner) waitLimitReached() bool {
+	return r.MaxConsecutiveWaits > 0 && r.consecutiveWaits >= r.MaxConsecutiveWaits
+}
+
+// waitLimitNote demands an executable action once the wait limit is hit.
+func (r *Runner) waitLimitNote() string {
+	if !r.waitLimitReached() {
+		return ""
+	}
+	return fmt.Sprintf("You have waited %d cycles in a row; waiting is no longer acceptable. Return an executable post_offer, create_rfq, trade or cancel now, sized small if unsure",
+		r.consecutiveWaits)
+}
+
+// heuristicAction is the one-cycle fallback used when the model keeps waiting
+// past the limit: sell one unit of a holding into the best visible bid, or
+// else buy one unit at the cheapest affordable ask. It reports false when
+// the book offers neither.
+func (r *Runner) heuristicAction() (Action, bool) {
+	reason := fmt.Sprintf("heuristic fallback after %d consecutive waits", r.consecutiveWaits)
+	held := []string{}
+	for denom, amount := range r.lastBalances {
+		if denom != r.settlement() && amount >= 1 && r.tokenAllowed(denom) {
+			held = append(held, denom)
+		}
+	}
+	sort.Strings(held)
+	for _, asset := range held {
+		if best, ok := bestLevel(r.bookLevels("sell", asset), "sell"); ok {
+			return Action{Action: "trade", Side: "sell", AssetSymbol: asset, PriceAGC: best.price, Qty: 1, Reason: reason}, true
+		}
+	}
+	budget := float64(r.spendableAGC())
+	var pick Action
+	found := false
+	for _, offer := range r.lastOffers {
+		asset := strings.ToUpper(strings.TrimSpace(offer.Asset))
+		if offer.AgentID == r.AgentID || !isOpenStatus(offer.Status) || offer.Qty < 1 || !r.tokenAllowed(asset) {
+			continue
+		}
+		if offer.PriceAGC <= 0 || offer.PriceAGC > budget {
+			continue
+		}
+		if !found || offer.PriceAGC < pick.PriceAGC {
+			pick = Action{Action: "trade", Side: "buy", AssetSymbol: asset, PriceAGC: offer.PriceAGC, Qty: 1, Reason: reason}
+			found = true
+		}
+	}
+	return pick, found
+}
+
+func bestLevel(levels []bookLevel, side string) (bookLevel, bool) {
+	var best bookLevel
+	found := false
+	for _, level := range levels {
+		if level.qty < 1 {
+			continue
+		}
+		if !found || (side == "sell" && level.price > best.price) || (side == "buy" && level.price < best.price) {
+			best, found = level, true
+		}
+	}
+	return best, found
+}
+
+// tokenAllowed checks symbol against the effective allowed-token list; an
+// empty list allows any non-settlement token.
+func (r *Runner) tokenAllowed(symbol string) bool {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" || symbol == r.settlement() {
+		return false
+	}
+	if len(r.allowedTokens) == 0 {
+		return true
+	}
+	for _, allowed := range r.allowedTokens {
+		if strings.ToUpper(strings.TrimSpace(allowed)) == symbol {
+			return true
+		}
+	}
+	return false
+}
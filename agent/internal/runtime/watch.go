@@ -0,0 +1,139 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"agentmarket/agent/internal/indexer"
+)
+
+// Watch is an optional condition attached to a wait action. While it is
+// armed the runner polls fresh market data every tick and decides again as
+// soon as the condition holds, instead of sleeping out next_check_sec.
+type Watch struct {
+	AssetSymbol string  `json:"asset_symbol"`
+	Trigger     string  `json:"trigger"`
+	PriceAGC    float64 `json:"price_agc"`
+}
+
+// watchTriggers maps each trigger to what it compares against price_agc.
+var watchTriggers = map[string]string{
+	"ask_below":   "best ask",
+	"bid_above":   "best bid",
+	"price_below": "token price",
+	"price_above": "token price",
+}
+
+func (w Watch) String() string {
+	return fmt.Sprintf("%s %s %.4f", w.AssetSymbol, w.Trigger, w.PriceAGC)
+}
+
+func normalizeWatch(watch *Watch) {
+	if watch == nil {
+		return
+	}
+	watch.AssetSymbol = strings.ToUpper(strings.TrimSpace(watch.AssetSymbol))
+	watch.Trigger = strings.ToLower(strings.TrimSpace(watch.Trigger))
+}
+
+func validateWatch(watch *Watch, settlement string) string {
+	if watch == nil {
+		return ""
+	}
+	if watch.AssetSymbol == "" {
+		return "watch.asset_symbol is required"
+	}
+	if watch.AssetSymbol == settlement {
+		return "watch.asset_symbol must not be " + settlement
+	}
+	if _, ok := watchTriggers[watch.Trigger]; !ok {
+		return "watch.trigger must be ask_below, bid_above, price_below or price_above"
+	}
+	if !isFinite(watch.PriceAGC) || watch.PriceAGC <= 0 {
+		return "watch.price_agc must be > 0"
+	}
+	return ""
+}
+
+// watchTriggered fetches the data the armed watch needs and reports whether
+// its condition now holds, disarming it if so. Fetch errors keep it armed.
+func (r *Runner) watchTriggered(ctx context.Context) bool {
+	if r.watch == nil || r.Indexer == nil {
+		return false
+	}
+	watch := *r.watch
+	fetchCtx, cancel := context.WithTimeout(ctx, r.Timeouts.Fetch)
+	defer cancel()
+	observed, ok, err := r.watchObserve(fetchCtx, watch)
+	if err != nil {
+		r.logf(logWarn, "watch %s: %v", watch, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	hit := false
+	switch watch.Trigger {
+	case "ask_below", "price_below":
+		hit = observed <= watch.PriceAGC
+	case "bid_above", "price_above":
+		hit = observed >= watch.PriceAGC
+	}
+	if !hit {
+		return false
+	}
+	r.watch = nil
+	r.watchNote = fmt.Sprintf("Woke early: watch %s hit (%s %.4f)", watch, watchTriggers[watch.Trigger], observed)
+	r.logf(logInfo, "watch %s hit at %.4f; deciding early", watch, observed)
+	return true
+}
+
+// watchObserve returns the current value a watch compares, and false when
+// the market has none (no other agent's offers or RFQs, or an unlisted token).
+func (r *Runner) watchObserve(ctx context.Context, watch Watch) (float64, bool, error) {
+	query := indexer.ListQuery{Status: "open", Assets: []string{watch.AssetSymbol}}
+	switch watch.Trigger {
+	case "ask_below":
+		offers, err := r.Indexer.GetOffers(ctx, query)
+		if err != nil {
+			return 0, false, err
+		}
+		best, found := 0.0, false
+		for _, offer := range offers {
+			if offer.AgentID == r.AgentID || offer.Qty <= 0 || offer.PriceAGC <= 0 {
+				continue
+			}
+			if !found || offer.PriceAGC < best {
+				best, found = offer.PriceAGC, true
+			}
+		}
+		return best, found, nil
+	case "bid_above":
+		rfqs, err := r.Indexer.GetRFQs(ctx, query)
+		if err != nil {
+			return 0, false, err
+		}
+		best, found := 0.0, false
+		for _, rfq := range rfqs {
+			if rfq.AgentID == r.AgentID || rfq.Qty <= 0 {
+				continue
+			}
+			if !found || rfq.MaxPriceAGC > best {
+				best, found = rfq.MaxPriceAGC, true
+			}
+		}
+		return best, found, nil
+	default:
+		tokens, err := r.Indexer.GetTokens(ctx)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, token := range tokens {
+			if strings.EqualFold(token.Symbol, watch.AssetSymbol) && token.PriceAGC > 0 {
+				return token.PriceAGC, true, nil
+			}
+		}
+		return 0, false, nil
+	}
+}
@@ -4,6 +4,7 @@ set -euo pipefail
 ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
 AGENT_DIR="$ROOT_DIR/agent"
 OUT_DIR="$AGENT_DIR/dist"
+VERSION="${AGENTD_VERSION:-$(git -C "$ROOT_DIR" describe --tags --always --dirty 2>/dev/null || echo dev)}"
 
 mkdir -p "$OUT_DIR"
 
@@ -15,7 +16,7 @@ build_target() {
   local target_dir="$OUT_DIR/$out_folder"
   mkdir -p "$target_dir"
   echo "[agentd] building $goos/$goarch -> $target_dir/$out_name"
-  (cd "$AGENT_DIR" && CGO_ENABLED=0 GOOS="$goos" GOARCH="$goarch" go build -o "$target_dir/$out_name" ./cmd/agentd)
+  (cd "$AGENT_DIR" && CGO_ENABLED=0 GOOS="$goos" GOARCH="$goarch" go build -ldflags "-X main.version=$VERSION" -o "$target_dir/$out_name" ./cmd/agentd)
 }
 
 build_target "darwin" "amd64" "darwin-amd64" "agentd"